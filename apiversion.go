@@ -0,0 +1,84 @@
+package echozap
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// VersionFromPathPrefix returns an APIVersionExtractor reading the version from the first path segment (e.g. /v2/users)
+func VersionFromPathPrefix() func(c echo.Context) (string, bool) {
+	return func(c echo.Context) (string, bool) {
+		path := strings.TrimPrefix(c.Request().URL.Path, "/")
+		if i := strings.IndexByte(path, '/'); i >= 0 {
+			path = path[:i]
+		}
+		if path == "" || path[0] != 'v' && path[0] != 'V' {
+			return "", false
+		}
+		return normalizeAPIVersion(path)
+	}
+}
+
+// VersionFromHeader returns an APIVersionExtractor reading the version from a request header (e.g. X-API-Version: 2)
+func VersionFromHeader(name string) func(c echo.Context) (string, bool) {
+	return func(c echo.Context) (string, bool) {
+		return normalizeAPIVersion(c.Request().Header.Get(name))
+	}
+}
+
+// VersionFromAcceptVendor returns an APIVersionExtractor reading the version from a vendor media type of the Accept header
+// following prefix, e.g. application/vnd.acme.v3+json with the prefix application/vnd.acme.
+func VersionFromAcceptVendor(prefix string) func(c echo.Context) (string, bool) {
+	prefix = strings.ToLower(prefix)
+	return func(c echo.Context) (string, bool) {
+		for _, mediaRange := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+			mt := mediaType(mediaRange)
+			if !strings.HasPrefix(mt, prefix) {
+				continue
+			}
+			version := mt[len(prefix):]
+			if i := strings.IndexByte(version, '+'); i >= 0 {
+				version = version[:i]
+			}
+			if v, ok := normalizeAPIVersion(version); ok {
+				return v, true
+			}
+		}
+		return "", false
+	}
+}
+
+// normalizeAPIVersion returns a version made of dot separated numbers, with an optional v, as v followed by the numbers
+func normalizeAPIVersion(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s != "" && (s[0] == 'v' || s[0] == 'V') {
+		s = s[1:]
+	}
+	if s == "" {
+		return "", false
+	}
+	for _, part := range strings.Split(s, ".") {
+		if part == "" {
+			return "", false
+		}
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return "", false
+			}
+		}
+	}
+	return "v" + s, true
+}
+
+// apiVersionFields returns the version found by the first matching extractor
+func apiVersionFields(c echo.Context, extractors []func(c echo.Context) (string, bool)) []zapcore.Field {
+	for _, extract := range extractors {
+		if v, ok := extract(c); ok {
+			return []zapcore.Field{zap.String("api_version", v)}
+		}
+	}
+	return nil
+}
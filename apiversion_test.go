@@ -0,0 +1,96 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestVersionExtractors(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor func(c echo.Context) (string, bool)
+		path      string
+		header    string
+		value     string
+		version   string
+	}{
+		{name: "path", extractor: VersionFromPathPrefix(), path: "/v2/users", version: "v2"},
+		{name: "path minor", extractor: VersionFromPathPrefix(), path: "/V1.1", version: "v1.1"},
+		{name: "path word", extractor: VersionFromPathPrefix(), path: "/videos/1"},
+		{name: "path unversioned", extractor: VersionFromPathPrefix(), path: "/users/v2"},
+		{name: "header", extractor: VersionFromHeader("X-API-Version"), header: "X-API-Version", value: " 3 ", version: "v3"},
+		{name: "header prefixed", extractor: VersionFromHeader("X-API-Version"), header: "X-API-Version", value: "v2.0", version: "v2.0"},
+		{name: "header invalid", extractor: VersionFromHeader("X-API-Version"), header: "X-API-Version", value: "latest"},
+		{name: "header missing", extractor: VersionFromHeader("X-API-Version")},
+		{
+			name:      "accept",
+			extractor: VersionFromAcceptVendor("application/vnd.acme."),
+			header:    echo.HeaderAccept,
+			value:     "text/html, application/vnd.acme.v3+json; q=0.9",
+			version:   "v3",
+		},
+		{
+			name:      "accept other vendor",
+			extractor: VersionFromAcceptVendor("application/vnd.acme."),
+			header:    echo.HeaderAccept,
+			value:     "application/vnd.github.v3+json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			if path == "" {
+				path = "/"
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			version, ok := tt.extractor(c)
+			assert.Equal(t, tt.version != "", ok)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestZapLoggerAPIVersion(t *testing.T) {
+	extractors := []func(c echo.Context) (string, bool){
+		VersionFromPathPrefix(),
+		VersionFromHeader("X-API-Version"),
+		VersionFromAcceptVendor("application/vnd.acme."),
+	}
+
+	serve := func(path string, headers map[string]string) interface{} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		obs, logs := observer.New(zap.DebugLevel)
+
+		logger := zap.New(obs)
+
+		assert.Nil(t, ZapLoggerWithConfig(logger, ZapLoggerConfig{APIVersionExtractors: extractors})(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})(c))
+
+		return logs.AllUntimed()[0].ContextMap()["api_version"]
+	}
+
+	everything := map[string]string{"X-API-Version": "4", echo.HeaderAccept: "application/vnd.acme.v5+json"}
+	assert.Equal(t, "v2", serve("/v2/users", everything))
+	assert.Equal(t, "v4", serve("/users", everything))
+	assert.Equal(t, "v5", serve("/users", map[string]string{echo.HeaderAccept: "application/vnd.acme.v5+json"}))
+	assert.Nil(t, serve("/users", nil))
+}
@@ -0,0 +1,83 @@
+package echozap
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// validatorFieldError matches the errors of a validator.ValidationErrors without depending on the validator package
+type validatorFieldError interface {
+	Field() string
+	Tag() string
+}
+
+type validationError struct {
+	field      string
+	constraint string
+}
+
+func (v validationError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("field", v.field)
+	enc.AddString("constraint", v.constraint)
+	return nil
+}
+
+type validationErrors []validationError
+
+func (v validationErrors) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, e := range v {
+		if err := enc.AppendObject(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindErrorFields returns the details of a bind or validation error.
+// Nothing is returned when the underlying error is not recognized.
+func bindErrorFields(err error) []zapcore.Field {
+	for err != nil {
+		switch e := err.(type) {
+		case *json.SyntaxError:
+			return []zapcore.Field{zap.Int64("bind_error_offset", e.Offset)}
+		case *json.UnmarshalTypeError:
+			return []zapcore.Field{zap.Array("validation_errors", validationErrors{
+				{field: e.Field, constraint: "type=" + e.Type.String()},
+			})}
+		}
+
+		if v := fieldErrors(err); len(v) > 0 {
+			return []zapcore.Field{zap.Array("validation_errors", v)}
+		}
+
+		if he, ok := err.(*echo.HTTPError); ok {
+			err = he.Internal
+		} else {
+			err = errors.Unwrap(err)
+		}
+	}
+	return nil
+}
+
+// fieldErrors returns the failed fields of an error that is a slice of field errors, like validator.ValidationErrors
+func fieldErrors(err error) validationErrors {
+	v := reflect.ValueOf(err)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+
+	var errs validationErrors
+	for i := 0; i < v.Len(); i++ {
+		fe, ok := v.Index(i).Interface().(validatorFieldError)
+		if !ok {
+			return nil
+		}
+		errs = append(errs, validationError{field: fe.Field(), constraint: fe.Tag()})
+	}
+	return errs
+}
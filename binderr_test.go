@@ -0,0 +1,142 @@
+package echozap
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testFieldError struct {
+	field string
+	tag   string
+}
+
+func (e testFieldError) Field() string { return e.field }
+func (e testFieldError) Tag() string   { return e.tag }
+func (e testFieldError) Error() string { return e.field + " failed on " + e.tag }
+
+// testValidationErrors has the shape of validator.ValidationErrors
+type testValidationErrors []testFieldError
+
+func (e testValidationErrors) Error() string { return "validation failed" }
+
+type testValidator struct{}
+
+func (testValidator) Validate(i interface{}) error {
+	u := i.(*testUser)
+	var errs testValidationErrors
+	if u.Name == "" {
+		errs = append(errs, testFieldError{field: "name", tag: "required"})
+	}
+	if u.Age < 18 {
+		errs = append(errs, testFieldError{field: "age", tag: "gte"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type testUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestZapLoggerBindErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields map[string]interface{}
+	}{
+		{
+			name:   "syntax error",
+			body:   `{"name": "bob",}`,
+			fields: map[string]interface{}{"bind_error_offset": int64(16)},
+		},
+		{
+			name: "type error",
+			body: `{"name": "bob", "age": "old"}`,
+			fields: map[string]interface{}{"validation_errors": []interface{}{
+				map[string]interface{}{"field": "age", "constraint": "type=int"},
+			}},
+		},
+		{
+			name: "validation error",
+			body: `{"age": 12}`,
+			fields: map[string]interface{}{"validation_errors": []interface{}{
+				map[string]interface{}{"field": "name", "constraint": "required"},
+				map[string]interface{}{"field": "age", "constraint": "gte"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = testValidator{}
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				var u testUser
+				if err := c.Bind(&u); err != nil {
+					return err
+				}
+				if err := c.Validate(&u); err != nil {
+					return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+				}
+				return c.NoContent(http.StatusCreated)
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{LogBindErrors: true})(h)(c)
+
+			assert.Nil(t, err)
+
+			logFields := logs.AllUntimed()[0].ContextMap()
+
+			assert.Equal(t, int64(http.StatusBadRequest), logFields["status"])
+			for k, v := range tt.fields {
+				assert.Equal(t, v, logFields[k], k)
+			}
+		})
+	}
+}
+
+func TestZapLoggerBindErrorsUnrecognized(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(errors.New("bad input"))
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{LogBindErrors: true})(h)(c)
+
+	assert.Nil(t, err)
+
+	logFields := logs.AllUntimed()[0].ContextMap()
+
+	assert.NotContains(t, logFields, "validation_errors")
+	assert.NotContains(t, logFields, "bind_error_offset")
+	assert.NotNil(t, logFields["error"])
+}
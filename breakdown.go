@@ -0,0 +1,60 @@
+package echozap
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// handlerStartKey is the context key where MarkHandler stores the time the handler started
+const handlerStartKey = "echozap.handler_start"
+
+// MarkHandler is a middleware recording when the handler starts, so the latency breakdown can tell the time spent
+// in the middlewares registered after ZapLogger from the time spent in the handler.
+// It should be registered last, or as a route middleware.
+func MarkHandler() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(handlerStartKey, requestClock(c)())
+			return next(c)
+		}
+	}
+}
+
+// latencyBreakdown records the phases of a request using the response hooks
+type latencyBreakdown struct {
+	start      time.Time
+	firstWrite time.Time
+}
+
+// newLatencyBreakdown starts the breakdown of the request, timing its phases with now
+func newLatencyBreakdown(c echo.Context, start time.Time, now func() time.Time) *latencyBreakdown {
+	b := &latencyBreakdown{start: start}
+	c.Response().Before(func() {
+		if b.firstWrite.IsZero() {
+			b.firstWrite = now()
+		}
+	})
+	return b
+}
+
+func (b *latencyBreakdown) fields(c echo.Context, end time.Time) []zapcore.Field {
+	handlerStart := b.start
+	if t, ok := c.Get(handlerStartKey).(time.Time); ok && t.After(b.start) {
+		handlerStart = t
+	}
+
+	// Requests that never wrote spent everything in the handler
+	handlerEnd, write := end, time.Duration(0)
+	if !b.firstWrite.IsZero() && b.firstWrite.After(handlerStart) {
+		handlerEnd, write = b.firstWrite, end.Sub(b.firstWrite)
+	}
+
+	return []zapcore.Field{
+		zap.Stringer("latency_pre_handler", handlerStart.Sub(b.start)),
+		zap.Stringer("latency_handler", handlerEnd.Sub(handlerStart)),
+		zap.Stringer("latency_write", write),
+	}
+}
@@ -0,0 +1,83 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerLatencyBreakdown(t *testing.T) {
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{IncludeLatencyBreakdown: true})
+	defer handle.Close()
+
+	clock := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	handle.now = func() time.Time { return clock }
+
+	e.Use(mw)
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			clock = clock.Add(20 * time.Millisecond)
+			return next(c)
+		}
+	})
+	e.Use(MarkHandler())
+
+	e.GET("/write", func(c echo.Context) error {
+		clock = clock.Add(40 * time.Millisecond)
+		c.Response().WriteHeader(http.StatusOK)
+		clock = clock.Add(5 * time.Millisecond)
+		_, err := c.Response().Write([]byte("ok"))
+		return err
+	})
+	e.GET("/nowrite", func(c echo.Context) error {
+		clock = clock.Add(40 * time.Millisecond)
+		return nil
+	})
+
+	for _, path := range []string{"/write", "/nowrite"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+	}
+
+	assert.Equal(t, 2, logs.Len())
+
+	tests := []struct {
+		pre, handler, write time.Duration
+	}{
+		{pre: 20 * time.Millisecond, handler: 40 * time.Millisecond, write: 5 * time.Millisecond},
+		{pre: 20 * time.Millisecond, handler: 40 * time.Millisecond},
+	}
+
+	for i, entry := range logs.AllUntimed() {
+		logFields := entry.ContextMap()
+
+		pre := parseDuration(t, logFields["latency_pre_handler"])
+		handler := parseDuration(t, logFields["latency_handler"])
+		write := parseDuration(t, logFields["latency_write"])
+		total := parseDuration(t, logFields["latency"])
+
+		assert.Equal(t, tests[i].pre, pre)
+		assert.Equal(t, tests[i].handler, handler)
+		assert.Equal(t, tests[i].write, write)
+		assert.Equal(t, total, pre+handler+write)
+	}
+}
+
+func parseDuration(t *testing.T, v interface{}) time.Duration {
+	d, err := time.ParseDuration(v.(string))
+	assert.Nil(t, err)
+	return d
+}
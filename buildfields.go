@@ -0,0 +1,104 @@
+package echozap
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldOption configures the fields returned by BuildFields
+type FieldOption func(*fieldOptions)
+
+type fieldOptions struct {
+	only, exclude []string
+	remoteIP      string
+	hasRemoteIP   bool
+	err           error
+}
+
+// WithRemoteIP sets the logged remote_ip instead of deriving it from the request like echo.Context.RealIP
+func WithRemoteIP(ip string) FieldOption {
+	return func(o *fieldOptions) {
+		o.remoteIP, o.hasRemoteIP = ip, true
+	}
+}
+
+// WithError adds the error of the request, logged like the middleware does for client and server errors
+func WithError(err error) FieldOption {
+	return func(o *fieldOptions) {
+		o.err = err
+	}
+}
+
+// WithOnlyFields is the equivalent of ZapLoggerConfig.OnlyFields
+func WithOnlyFields(names ...string) FieldOption {
+	return func(o *fieldOptions) {
+		o.only = append(o.only, names...)
+	}
+}
+
+// WithExcludeFields is the equivalent of ZapLoggerConfig.ExcludeFields
+func WithExcludeFields(names ...string) FieldOption {
+	return func(o *fieldOptions) {
+		o.exclude = append(o.exclude, names...)
+	}
+}
+
+// BuildFields returns the built-in fields of the access log entry of a request served outside of echo,
+// e.g. by a plain net/http handler, so both share the same shape. It panics on unknown field names.
+func BuildFields(req *http.Request, status int, size int64, latency time.Duration, requestID string, opts ...FieldOption) []zapcore.Field {
+	var o fieldOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	mask, err := newFieldMask(o.only, o.exclude)
+	if err != nil {
+		panic(err)
+	}
+
+	if !o.hasRemoteIP {
+		o.remoteIP = realIP(req)
+	}
+
+	fields := buildFields(req, status, size, latency, requestID, o.remoteIP, mask)
+
+	if level, _ := statusLevel(status); level >= zapcore.WarnLevel && mask.has(fieldError) {
+		fields = append([]zapcore.Field{zap.Error(o.err)}, fields...)
+	}
+
+	return fields
+}
+
+// buildFields returns the built-in fields in the mask, except the error
+func buildFields(req *http.Request, status int, size int64, latency time.Duration, requestID, remoteIP string, mask fieldMask) []zapcore.Field {
+	fields := []zapcore.Field{
+		zap.String(fieldRemoteIP.name(), remoteIP),
+		zap.Stringer(fieldLatency.name(), latency),
+		zap.String(fieldHost.name(), req.Host),
+		zap.String(fieldRequest.name(), fmt.Sprintf("%s %s", req.Method, req.RequestURI)),
+		zap.Int(fieldStatus.name(), status),
+		zap.Int64(fieldSize.name(), size),
+		zap.String(fieldUserAgent.name(), req.UserAgent()),
+		zap.String(fieldRequestID.name(), requestID),
+	}
+
+	return mask.filter(fields)
+}
+
+// realIP is the equivalent of echo.Context.RealIP for a plain request
+func realIP(req *http.Request) string {
+	if ip := req.Header.Get("X-Forwarded-For"); ip != "" {
+		return strings.Split(ip, ", ")[0]
+	}
+	if ip := req.Header.Get("X-Real-Ip"); ip != "" {
+		return ip
+	}
+	ra, _, _ := net.SplitHostPort(req.RemoteAddr)
+	return ra
+}
@@ -0,0 +1,68 @@
+package echozap
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestBuildFields(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/something?q=1", nil)
+		req.Header.Set(echo.HeaderXRequestID, "abc-123")
+		req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.7, 10.0.0.1")
+		req.Header.Set("User-Agent", "test-agent")
+		return req
+	}
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newRequest(), rec)
+
+	failure := errors.New("unavailable")
+	h := func(c echo.Context) error {
+		return c.String(http.StatusBadGateway, "upstream failed")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{ExcludeFields: []string{"host"}})
+	clock := time.Unix(0, 0)
+	handle.now = func() time.Time {
+		clock = clock.Add(25 * time.Millisecond)
+		return clock
+	}
+
+	assert.Nil(t, mw(func(c echo.Context) error {
+		_ = h(c)
+		return nil
+	})(c))
+
+	assert.Equal(t, 1, logs.Len())
+
+	fields := BuildFields(newRequest(), http.StatusBadGateway, int64(len("upstream failed")), 25*time.Millisecond, "abc-123",
+		WithExcludeFields("host"))
+	assert.Equal(t, logs.AllUntimed()[0].Context, fields)
+	assert.Equal(t, "198.51.100.7", fields[1].String)
+
+	fields = BuildFields(newRequest(), http.StatusBadGateway, 0, time.Second, "abc-123",
+		WithError(failure), WithRemoteIP("203.0.113.9"), WithOnlyFields("error", "remote_ip", "status"))
+	assert.Equal(t, 3, len(fields))
+	assert.Equal(t, failure, fields[0].Interface)
+	assert.Equal(t, "203.0.113.9", fields[1].String)
+	assert.Equal(t, int64(http.StatusBadGateway), fields[2].Integer)
+
+	fields = BuildFields(newRequest(), http.StatusOK, 0, time.Second, "", WithError(failure))
+	assert.Equal(t, "remote_ip", fields[0].Key)
+
+	assert.Panics(t, func() { BuildFields(newRequest(), http.StatusOK, 0, 0, "", WithOnlyFields("nope")) })
+}
@@ -0,0 +1,116 @@
+package echozap
+
+import (
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// canonicalKey is the context key holding the values recorded by Count, Timing, Set and StartPhase
+const canonicalKey = "echozap.canonical"
+
+type canonicalKind uint8
+
+const (
+	canonicalCount canonicalKind = iota
+	canonicalTiming
+	canonicalValue
+)
+
+type canonicalValueEntry struct {
+	key   string
+	kind  canonicalKind
+	count int64
+	dur   time.Duration
+	value interface{}
+}
+
+// canonicalLine holds the values recorded by the handler for the access log entry, in the order they were first recorded.
+// There are usually a handful of keys, so they are looked up linearly.
+type canonicalLine struct {
+	mu      sync.Mutex
+	entries []canonicalValueEntry
+	// phases are the phases started by StartPhase that did not end yet
+	phases []*openPhase
+}
+
+// Count adds n to the counter key of the access log entry of the request
+func Count(c echo.Context, key string, n int64) {
+	line := canonical(c)
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	e := line.entry(key, canonicalCount)
+	e.count += n
+}
+
+// Timing adds d to the duration key of the access log entry of the request
+func Timing(c echo.Context, key string, d time.Duration) {
+	line := canonical(c)
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	e := line.entry(key, canonicalTiming)
+	e.dur += d
+}
+
+// Set sets the value of key in the access log entry of the request, replacing any previous value
+func Set(c echo.Context, key string, value interface{}) {
+	line := canonical(c)
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	e := line.entry(key, canonicalValue)
+	e.value = value
+}
+
+// canonical returns the values of the request, created on first use
+func canonical(c echo.Context) *canonicalLine {
+	if line, ok := c.Get(canonicalKey).(*canonicalLine); ok {
+		return line
+	}
+	line := &canonicalLine{}
+	c.Set(canonicalKey, line)
+	return line
+}
+
+// entry returns the entry for key, resetting it when it was recorded with another kind
+func (l *canonicalLine) entry(key string, kind canonicalKind) *canonicalValueEntry {
+	for i := range l.entries {
+		if e := &l.entries[i]; e.key == key {
+			if e.kind != kind {
+				*e = canonicalValueEntry{key: key, kind: kind}
+			}
+			return e
+		}
+	}
+	l.entries = append(l.entries, canonicalValueEntry{key: key, kind: kind})
+	return &l.entries[len(l.entries)-1]
+}
+
+// canonicalFields returns the values recorded during the request
+func canonicalFields(c echo.Context) []zapcore.Field {
+	line, ok := c.Get(canonicalKey).(*canonicalLine)
+	if !ok {
+		return nil
+	}
+
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	fields := make([]zapcore.Field, 0, len(line.entries))
+	for _, e := range line.entries {
+		switch e.kind {
+		case canonicalCount:
+			fields = append(fields, zap.Int64(e.key, e.count))
+		case canonicalTiming:
+			fields = append(fields, zap.Duration(e.key, e.dur))
+		default:
+			fields = append(fields, zap.Any(e.key, e.value))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,72 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerCanonicalLine(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		Count(c, "db_queries", 1)
+		Timing(c, "ext_api", 80*time.Millisecond)
+		Count(c, "cache_hits", 7)
+		Count(c, "db_queries", 2)
+		Set(c, "plan", "free")
+		Timing(c, "ext_api", 40*time.Millisecond)
+		Set(c, "plan", "pro")
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	err := ZapLogger(logger)(h)(c)
+
+	assert.Nil(t, err)
+
+	entry := logs.AllUntimed()[0]
+	logFields := entry.ContextMap()
+
+	assert.Equal(t, int64(3), logFields["db_queries"])
+	assert.Equal(t, int64(7), logFields["cache_hits"])
+	assert.Equal(t, 120*time.Millisecond, logFields["ext_api"])
+	assert.Equal(t, "pro", logFields["plan"])
+
+	var keys []string
+	for _, f := range entry.Context[len(entry.Context)-4:] {
+		keys = append(keys, f.Key)
+	}
+	assert.Equal(t, []string{"db_queries", "ext_api", "cache_hits", "plan"}, keys)
+}
+
+func TestZapLoggerCanonicalLineUnused(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	assert.Nil(t, ZapLogger(logger)(h)(c))
+	assert.Nil(t, c.Get(canonicalKey))
+	assert.Equal(t, 8, len(logs.AllUntimed()[0].Context))
+}
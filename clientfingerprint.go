@@ -0,0 +1,99 @@
+package echozap
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FingerprintConfig defines the client_fingerprint field, a keyed hash grouping the requests of a client
+// without logging its address
+type FingerprintConfig struct {
+	// Secret key of the HMAC. Rotating it changes all the fingerprints
+	Secret []byte
+	// Number of leading bits of IPv4 addresses hashed. Defaults to 24
+	IPv4Mask int
+	// Number of leading bits of IPv6 addresses hashed. Defaults to 64
+	IPv6Mask int
+}
+
+// clientFingerprintLength is the number of hex characters of the fingerprint
+const clientFingerprintLength = 12
+
+// clientFingerprinter computes the fingerprints with pooled HMACs
+type clientFingerprinter struct {
+	ipv4Mask, ipv6Mask int
+	pool               sync.Pool
+}
+
+func newClientFingerprinter(config *FingerprintConfig) *clientFingerprinter {
+	if config == nil {
+		return nil
+	}
+
+	f := &clientFingerprinter{ipv4Mask: config.IPv4Mask, ipv6Mask: config.IPv6Mask}
+	if f.ipv4Mask <= 0 || f.ipv4Mask > 32 {
+		f.ipv4Mask = 24
+	}
+	if f.ipv6Mask <= 0 || f.ipv6Mask > 128 {
+		f.ipv6Mask = 64
+	}
+
+	secret := append([]byte(nil), config.Secret...)
+	f.pool.New = func() interface{} {
+		return hmac.New(sha256.New, secret)
+	}
+	return f
+}
+
+// fingerprint hashes the masked client IP and the User-Agent, trimmed and lowercased.
+// An invalid IP is hashed as an empty one.
+func (f *clientFingerprinter) fingerprint(ip, userAgent string) string {
+	h := f.pool.Get().(hash.Hash)
+	defer f.pool.Put(h)
+	h.Reset()
+
+	var buf [64]byte
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		addr = addr.Unmap()
+		bits := f.ipv6Mask
+		if addr.Is4() {
+			bits = f.ipv4Mask
+		}
+		if p, err := addr.Prefix(bits); err == nil {
+			b, _ := p.Addr().MarshalBinary()
+			h.Write(b)
+		}
+	}
+	// Separates the address from the User-Agent
+	h.Write([]byte{0})
+
+	// Lowercase the ASCII letters through a fixed buffer rather than strings.ToLower
+	ua := strings.TrimSpace(userAgent)
+	for len(ua) > 0 {
+		n := copy(buf[:], ua)
+		for i := 0; i < n; i++ {
+			if c := buf[i]; 'A' <= c && c <= 'Z' {
+				buf[i] = c + 'a' - 'A'
+			}
+		}
+		h.Write(buf[:n])
+		ua = ua[n:]
+	}
+
+	sum := h.Sum(buf[:0])
+	var out [clientFingerprintLength]byte
+	hex.Encode(out[:], sum[:clientFingerprintLength/2])
+	return string(out[:])
+}
+
+func (f *clientFingerprinter) fields(ip, userAgent string) []zapcore.Field {
+	return []zapcore.Field{zap.String("client_fingerprint", f.fingerprint(ip, userAgent))}
+}
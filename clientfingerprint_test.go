@@ -0,0 +1,63 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerClientFingerprint(t *testing.T) {
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		ClientFingerprint: &FingerprintConfig{Secret: []byte("s3cr3t")},
+	})
+
+	e := echo.New()
+	fingerprint := func(remoteAddr, userAgent string) string {
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("User-Agent", userAgent)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Nil(t, mw(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})(c))
+
+		entries := logs.AllUntimed()
+		return entries[len(entries)-1].ContextMap()["client_fingerprint"].(string)
+	}
+
+	ua := "Mozilla/5.0 (X11; Linux x86_64)"
+	first := fingerprint("198.51.100.7:1234", ua)
+	assert.Len(t, first, 12)
+	assert.Equal(t, first, fingerprint("198.51.100.7:5678", ua))
+	assert.Equal(t, first, fingerprint("198.51.100.200:1234", " MOZILLA/5.0 (x11; linux x86_64) "))
+	assert.NotEqual(t, first, fingerprint("198.51.101.7:1234", ua))
+	assert.NotEqual(t, first, fingerprint("198.51.100.7:1234", "curl/8.0"))
+
+	v6 := fingerprint("[2001:db8:1:2::1]:1234", ua)
+	assert.Equal(t, v6, fingerprint("[2001:db8:1:2:ffff::9]:1234", ua))
+	assert.NotEqual(t, v6, fingerprint("[2001:db8:1:3::1]:1234", ua))
+}
+
+func TestClientFingerprinter(t *testing.T) {
+	a := newClientFingerprinter(&FingerprintConfig{Secret: []byte("a")})
+	b := newClientFingerprinter(&FingerprintConfig{Secret: []byte("b")})
+	assert.NotEqual(t, a.fingerprint("192.0.2.1", "ua"), b.fingerprint("192.0.2.1", "ua"))
+
+	wide := newClientFingerprinter(&FingerprintConfig{Secret: []byte("a"), IPv4Mask: 16})
+	assert.Equal(t, wide.fingerprint("192.0.2.1", "ua"), wide.fingerprint("192.0.99.1", "ua"))
+	assert.NotEqual(t, a.fingerprint("192.0.2.1", "ua"), a.fingerprint("192.0.99.1", "ua"))
+
+	assert.Equal(t, a.fingerprint("not an ip", "ua"), a.fingerprint("", "ua"))
+	assert.Nil(t, newClientFingerprinter(nil))
+}
@@ -0,0 +1,178 @@
+package echozap
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultCompactFields are the fields compacted by CompactRepeats when CompactFields is empty
+var DefaultCompactFields = []string{"host", "user_agent"}
+
+// DefaultCompactResyncEvery is the number of entries between two base entries when CompactResyncEvery is not set
+const DefaultCompactResyncEvery = 100
+
+// repeatCompactor omits the fields repeating the values of the last base entry. The entries are written while the
+// compactor is locked, so a base always reaches the output before the entries referencing it.
+type repeatCompactor struct {
+	fields      map[string]bool
+	resyncEvery int
+
+	mu sync.Mutex
+	// seq numbers the bases, starting at 1
+	seq uint64
+	// since counts the entries since the last base
+	since int
+	base  []zapcore.Field
+}
+
+func newRepeatCompactor(fields []string, resyncEvery int) *repeatCompactor {
+	if len(fields) == 0 {
+		fields = DefaultCompactFields
+	}
+	if resyncEvery <= 0 {
+		resyncEvery = DefaultCompactResyncEvery
+	}
+
+	c := &repeatCompactor{fields: make(map[string]bool, len(fields)), resyncEvery: resyncEvery}
+	for _, f := range fields {
+		c.fields[f] = true
+	}
+	return c
+}
+
+// compact writes the entry with write, holding the compactor lock. A base entry gets repeat_base and the repeat_fields
+// it defines, while the other entries omit the fields equal to the base and reference it with repeat_of. Entries lacking
+// one of the fields of the base are written in full, since a decoder would restore it.
+// write reports whether the entry reached the output, the next entry is a new base when a base was lost.
+func (c *repeatCompactor) compact(fields []zapcore.Field, write func([]zapcore.Field) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seq := c.seq
+	if !write(c.next(fields)) && c.seq != seq {
+		c.base = nil
+	}
+}
+
+// next returns the fields of the entry to write. The compactor must be locked
+func (c *repeatCompactor) next(fields []zapcore.Field) []zapcore.Field {
+	if c.base == nil || c.since >= c.resyncEvery-1 {
+		return c.rebase(fields)
+	}
+	c.since++
+
+	compacted := make([]zapcore.Field, 0, len(fields)+1)
+	matched := 0
+	for _, f := range fields {
+		if b, ok := c.lookup(f.Key); ok {
+			matched++
+			if f.Equals(b) {
+				continue
+			}
+		}
+		compacted = append(compacted, f)
+	}
+	if matched != len(c.base) || len(compacted) == len(fields) {
+		return fields
+	}
+	return append(compacted, zap.Uint64("repeat_of", c.seq))
+}
+
+// rebase makes the entry the new base. The compactor must be locked
+func (c *repeatCompactor) rebase(fields []zapcore.Field) []zapcore.Field {
+	c.seq++
+	c.since = 0
+	c.base = c.base[:0]
+
+	var names []string
+	for _, f := range fields {
+		if c.fields[f.Key] && f.Type != zapcore.SkipType {
+			c.base = append(c.base, f)
+			names = append(names, f.Key)
+		}
+	}
+
+	return append(fields[:len(fields):len(fields)], zap.Uint64("repeat_base", c.seq), zap.Strings("repeat_fields", names))
+}
+
+func (c *repeatCompactor) lookup(key string) (zapcore.Field, bool) {
+	for _, b := range c.base {
+		if b.Key == key {
+			return b, true
+		}
+	}
+	return zapcore.Field{}, false
+}
+
+// repeatDecoderBases is the number of recent bases kept by RepeatDecoder
+const repeatDecoderBases = 16
+
+// RepeatDecoder restores the entries written with CompactRepeats, decoded from JSON, in the order they were written.
+// It keeps the last bases only, so their entries should be decoded shortly after them.
+type RepeatDecoder struct {
+	bases []repeatBase
+}
+
+type repeatBase struct {
+	seq    uint64
+	fields map[string]interface{}
+}
+
+// Decode restores the omitted fields of the entry in place and removes the repeat_base, repeat_fields and repeat_of markers.
+// It fails when the base of the entry is unknown.
+func (d *RepeatDecoder) Decode(entry map[string]interface{}) error {
+	if seq, ok := entry["repeat_base"]; ok {
+		base := repeatBase{seq: toUint64(seq), fields: make(map[string]interface{})}
+		names, _ := entry["repeat_fields"].([]interface{})
+		for _, name := range names {
+			if k, ok := name.(string); ok {
+				base.fields[k] = entry[k]
+			}
+		}
+
+		if len(d.bases) == repeatDecoderBases {
+			d.bases = append(d.bases[:0], d.bases[1:]...)
+		}
+		d.bases = append(d.bases, base)
+
+		delete(entry, "repeat_base")
+		delete(entry, "repeat_fields")
+		return nil
+	}
+
+	ref, ok := entry["repeat_of"]
+	if !ok {
+		return nil
+	}
+	seq := toUint64(ref)
+	for i := len(d.bases) - 1; i >= 0; i-- {
+		if d.bases[i].seq != seq {
+			continue
+		}
+		for k, v := range d.bases[i].fields {
+			if _, ok := entry[k]; !ok {
+				entry[k] = v
+			}
+		}
+		delete(entry, "repeat_of")
+		return nil
+	}
+	return fmt.Errorf("echozap: unknown repeat base %v", ref)
+}
+
+// toUint64 converts the sequence numbers decoded by encoding/json, as float64 or json.Number
+func toUint64(v interface{}) uint64 {
+	switch v := v.(type) {
+	case float64:
+		return uint64(v)
+	case fmt.Stringer:
+		n, _ := strconv.ParseUint(v.String(), 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,196 @@
+package echozap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRepeatCompactor(t *testing.T) {
+	c := newRepeatCompactor(nil, 3)
+
+	entry := func(host, ua string) []zapcore.Field {
+		return []zapcore.Field{zap.String("host", host), zap.String("user_agent", ua), zap.Int("status", 200)}
+	}
+	compact := func(fields []zapcore.Field) (written []zapcore.Field) {
+		c.compact(fields, func(fields []zapcore.Field) bool {
+			written = fields
+			return true
+		})
+		return written
+	}
+
+	assert.Equal(t, append(entry("a", "sdk"), zap.Uint64("repeat_base", 1), zap.Strings("repeat_fields", []string{"host", "user_agent"})),
+		compact(entry("a", "sdk")))
+	assert.Equal(t, []zapcore.Field{zap.Int("status", 200), zap.Uint64("repeat_of", 1)}, compact(entry("a", "sdk")))
+	assert.Equal(t, []zapcore.Field{zap.String("host", "b"), zap.Int("status", 200), zap.Uint64("repeat_of", 1)}, compact(entry("b", "sdk")))
+
+	// Resynchronized every 3 entries
+	assert.Equal(t, append(entry("b", "curl"), zap.Uint64("repeat_base", 2), zap.Strings("repeat_fields", []string{"host", "user_agent"})),
+		compact(entry("b", "curl")))
+
+	// Written in full when a field of the base is missing or nothing repeats
+	assert.Equal(t, entry("b", "curl")[:1], compact(entry("b", "curl")[:1]))
+	assert.Equal(t, entry("c", "wget"), compact(entry("c", "wget")))
+}
+
+func TestRepeatCompactorLostBase(t *testing.T) {
+	c := newRepeatCompactor(nil, 100)
+
+	fields := []zapcore.Field{zap.String("host", "a"), zap.String("user_agent", "sdk")}
+	var written [][]zapcore.Field
+	write := func(ok bool) func([]zapcore.Field) bool {
+		return func(fields []zapcore.Field) bool {
+			written = append(written, fields)
+			return ok
+		}
+	}
+
+	c.compact(fields, write(false))
+	c.compact(fields, write(true))
+	c.compact(fields, write(false))
+	c.compact(fields, write(true))
+
+	// The base lost by the output is replaced by the next entry, while a lost reference leaves the base in place
+	assert.Equal(t, zap.Uint64("repeat_base", 1), written[0][2])
+	assert.Equal(t, zap.Uint64("repeat_base", 2), written[1][2])
+	assert.Equal(t, zap.Uint64("repeat_of", 2), written[2][0])
+	assert.Equal(t, zap.Uint64("repeat_of", 2), written[3][0])
+}
+
+func TestRepeatDecoderUnknownBase(t *testing.T) {
+	var d RepeatDecoder
+	assert.EqualError(t, d.Decode(map[string]interface{}{"repeat_of": float64(3)}), "echozap: unknown repeat base 3")
+}
+
+// compactedLogger returns a logger writing JSON entries without the time to out
+func compactedLogger(out *bytes.Buffer) *zap.Logger {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = ""
+	return zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(out), zap.DebugLevel))
+}
+
+// decodeLines decodes the JSON entries of out, without their latency
+func decodeLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &entry))
+		delete(entry, "latency")
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestZapLoggerCompactRepeats(t *testing.T) {
+	var full, compacted bytes.Buffer
+
+	config := ZapLoggerConfig{FieldGroups: map[string]string{"remote_ip": "net", "host": "net"}}
+	plain := ZapLoggerWithConfig(compactedLogger(&full), config)
+
+	config.CompactRepeats = true
+	config.CompactFields = []string{"net", "user_agent"}
+	config.CompactResyncEvery = 10
+	compact := ZapLoggerWithConfig(compactedLogger(&compacted), config)
+
+	e := echo.New()
+	for i := 0; i < 25; i++ {
+		ua := "our-sdk/1.0"
+		if i%7 == 0 {
+			ua = "curl/8.0"
+		}
+		for _, mw := range []echo.MiddlewareFunc{plain, compact} {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/items/%d", i), nil)
+			req.Header.Set("User-Agent", ua)
+			req.Header.Set(echo.HeaderXRequestID, fmt.Sprintf("req-%d", i))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			assert.Nil(t, mw(h)(c))
+		}
+	}
+
+	assert.True(t, compacted.Len() < full.Len()*9/10, "%d bytes compacted from %d", compacted.Len(), full.Len())
+
+	want := decodeLines(t, &full)
+	got := decodeLines(t, &compacted)
+
+	var bases int
+	var d RepeatDecoder
+	for _, entry := range got {
+		if _, ok := entry["repeat_base"]; ok {
+			bases++
+		}
+		assert.Nil(t, d.Decode(entry))
+	}
+	assert.Equal(t, 3, bases)
+	assert.Equal(t, want, got)
+}
+
+func TestZapLoggerCompactRepeatsConcurrent(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.Write(p)
+	})), zap.DebugLevel))
+
+	h := ZapLoggerWithConfig(logger, ZapLoggerConfig{CompactRepeats: true, CompactResyncEvery: 5})(func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	})
+
+	e := echo.New()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			req.Header.Set("User-Agent", fmt.Sprintf("agent-%d", i%3))
+			req.Header.Set(echo.HeaderXRequestID, fmt.Sprintf("req-%d", i))
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			assert.Nil(t, h(c))
+		}(i)
+	}
+	wg.Wait()
+
+	entries := decodeLines(t, &out)
+	assert.Equal(t, 50, len(entries))
+
+	// The bases reach the output before the entries referencing them, so the stream is decoded in one pass
+	var d RepeatDecoder
+	for _, entry := range entries {
+		assert.Nil(t, d.Decode(entry))
+
+		var i int
+		_, err := fmt.Sscanf(entry["request_id"].(string), "req-%d", &i)
+		assert.Nil(t, err)
+		assert.Equal(t, fmt.Sprintf("agent-%d", i%3), entry["user_agent"])
+		assert.Equal(t, "example.com", entry["host"])
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,42 @@
+package echozap
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// conditionalFields returns the range and conditional request outcome fields.
+// Nothing is returned for plain requests.
+func conditionalFields(req *http.Request, res *echo.Response) []zapcore.Field {
+	var fields []zapcore.Field
+
+	if r := normalizeRange(req.Header.Get("Range")); r != "" {
+		fields = append(fields, zap.String("range", r))
+	}
+
+	switch res.Status {
+	case http.StatusPartialContent:
+		fields = append(fields, zap.Bool("partial", true))
+	case http.StatusNotModified:
+		fields = append(fields, zap.Bool("not_modified", true))
+		if etag := res.Header().Get("ETag"); etag != "" {
+			fields = append(fields, zap.String("etag", etag))
+		}
+	}
+
+	return fields
+}
+
+// normalizeRange lowercases the unit of a Range header and removes its whitespace, e.g. "Bytes=0-99, 200-" becomes
+// "bytes=0-99,200-"
+func normalizeRange(r string) string {
+	r = strings.Join(strings.Fields(r), "")
+	if i := strings.IndexByte(r, '='); i >= 0 {
+		r = strings.ToLower(r[:i]) + r[i:]
+	}
+	return r
+}
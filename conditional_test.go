@@ -0,0 +1,76 @@
+package echozap
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerConditionalRequestInfo(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 1000)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int64
+		fields  map[string]interface{}
+	}{
+		{
+			name:    "range",
+			headers: map[string]string{"Range": "bytes=0-99, 200-299"},
+			status:  http.StatusPartialContent,
+			fields:  map[string]interface{}{"range": "bytes=0-99,200-299", "partial": true},
+		},
+		{
+			name:    "not modified",
+			headers: map[string]string{"If-None-Match": `"v1"`},
+			status:  http.StatusNotModified,
+			fields:  map[string]interface{}{"not_modified": true, "etag": `"v1"`},
+		},
+		{
+			name:   "normal",
+			status: http.StatusOK,
+			fields: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/media", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				c.Response().Header().Set("ETag", `"v1"`)
+				http.ServeContent(c.Response(), c.Request(), "media.bin", time.Time{}, bytes.NewReader(content))
+				return nil
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{IncludeConditionalRequestInfo: true})(h)(c)
+
+			assert.Nil(t, err)
+
+			logFields := logs.AllUntimed()[0].ContextMap()
+
+			assert.Equal(t, tt.status, logFields["status"])
+			for _, k := range []string{"range", "partial", "not_modified", "etag"} {
+				assert.Equal(t, tt.fields[k], logFields[k], k)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package echozap
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type connContextKey struct{}
+
+// connState counts the requests served on a connection
+type connState struct {
+	requests atomic.Int64
+}
+
+// ConnContext is meant to be set as the http.Server ConnContext. It tracks the requests served on each connection
+// so the access log entries report requests_on_connection and connection_reused:
+//
+//	e.Server.ConnContext = echozap.ConnContext
+func ConnContext(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, &connState{})
+}
+
+// countConnRequest counts the request on its connection and returns its position on it, 0 when the server was not set
+// up with ConnContext. It is called for every request, including the ones whose entry is skipped or suppressed.
+func countConnRequest(req *http.Request) int64 {
+	state, ok := req.Context().Value(connContextKey{}).(*connState)
+	if !ok {
+		return 0
+	}
+	return state.requests.Add(1)
+}
+
+// connFields returns the connection reuse fields of the n-th request of its connection.
+// Nothing is returned when the server was not set up with ConnContext.
+func connFields(n int64, req *http.Request) []zapcore.Field {
+	if n == 0 {
+		return nil
+	}
+
+	fields := []zapcore.Field{
+		zap.Int64("requests_on_connection", n),
+		zap.Bool("connection_reused", n > 1),
+	}
+	if n == 1 && req.TLS != nil {
+		fields = append(fields, zap.Bool("tls_resumed", req.TLS.DidResume))
+	}
+	return fields
+}
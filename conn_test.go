@@ -0,0 +1,105 @@
+package echozap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerConnectionReuse(t *testing.T) {
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	e.Use(ZapLogger(logger))
+	e.GET("/something", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	srv := httptest.NewUnstartedServer(e)
+	srv.Config.ConnContext = ConnContext
+	srv.Start()
+	defer srv.Close()
+
+	client := srv.Client()
+	for i := 0; i < 3; i++ {
+		res, err := client.Get(srv.URL + "/something")
+		assert.Nil(t, err)
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, 3, len(entries))
+
+	for i, entry := range entries {
+		assert.Equal(t, int64(i+1), entry.ContextMap()["requests_on_connection"])
+		assert.Equal(t, i > 0, entry.ContextMap()["connection_reused"])
+	}
+}
+
+func TestZapLoggerConnectionReuseCountsSuppressed(t *testing.T) {
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{
+		HealthCheckPaths: []string{"/healthz"},
+		Skipper:          func(c echo.Context) bool { return c.Request().URL.Path == "/skipped" },
+	})
+	defer handle.Close()
+
+	e.Use(mw)
+	for _, path := range []string{"/healthz", "/skipped", "/something"} {
+		e.GET(path, func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		})
+	}
+
+	srv := httptest.NewUnstartedServer(e)
+	srv.Config.ConnContext = ConnContext
+	srv.Start()
+	defer srv.Close()
+
+	client := srv.Client()
+	for _, path := range []string{"/healthz", "/skipped", "/something"} {
+		res, err := client.Get(srv.URL + path)
+		assert.Nil(t, err)
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}
+
+	// The health check and the skipped request are not logged, but were served on the connection
+	entries := logs.AllUntimed()
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, int64(3), entries[0].ContextMap()["requests_on_connection"])
+	assert.Equal(t, true, entries[0].ContextMap()["connection_reused"])
+}
+
+func TestZapLoggerConnectionReuseNotConfigured(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	assert.Nil(t, ZapLogger(logger)(h)(c))
+	assert.NotContains(t, logs.AllUntimed()[0].ContextMap(), "requests_on_connection")
+}
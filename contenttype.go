@@ -0,0 +1,53 @@
+package echozap
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// contentTypeFields returns the media type of the response and its short format, nothing when it is not set
+func contentTypeFields(contentType string) []zapcore.Field {
+	mediaType := mediaType(contentType)
+	if mediaType == "" {
+		return nil
+	}
+	return []zapcore.Field{
+		zap.String("response_content_type", mediaType),
+		zap.String("format", contentFormat(mediaType)),
+	}
+}
+
+// mediaType strips the parameters of a Content-Type header and lowercases it
+func mediaType(contentType string) string {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// contentFormat maps a media type to json, xml, html, text, proto or other
+func contentFormat(mediaType string) string {
+	switch mediaType {
+	case "application/json", "text/json":
+		return "json"
+	case "application/xml", "text/xml":
+		return "xml"
+	case "text/html", "application/xhtml+xml":
+		return "html"
+	case "application/protobuf", "application/x-protobuf", "application/vnd.google.protobuf",
+		"application/grpc", "application/grpc+proto":
+		return "proto"
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case strings.HasSuffix(mediaType, "+xml"):
+		return "xml"
+	case strings.HasPrefix(mediaType, "text/"):
+		return "text"
+	}
+	return "other"
+}
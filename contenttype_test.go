@@ -0,0 +1,97 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContentFormat(t *testing.T) {
+	tests := []struct {
+		contentType string
+		mediaType   string
+		format      string
+	}{
+		{"application/json; charset=UTF-8", "application/json", "json"},
+		{"application/problem+json", "application/problem+json", "json"},
+		{"application/vnd.api+json", "application/vnd.api+json", "json"},
+		{"Application/XML", "application/xml", "xml"},
+		{"text/xml; charset=utf-8", "text/xml", "xml"},
+		{"application/atom+xml", "application/atom+xml", "xml"},
+		{"text/html; charset=utf-8", "text/html", "html"},
+		{"application/xhtml+xml", "application/xhtml+xml", "html"},
+		{"text/plain", "text/plain", "text"},
+		{"text/csv", "text/csv", "text"},
+		{"application/x-protobuf", "application/x-protobuf", "proto"},
+		{"application/grpc+proto", "application/grpc+proto", "proto"},
+		{"image/png", "image/png", "other"},
+		{"application/octet-stream", "application/octet-stream", "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			assert.Equal(t, tt.mediaType, mediaType(tt.contentType))
+			assert.Equal(t, tt.format, contentFormat(mediaType(tt.contentType)))
+		})
+	}
+
+	assert.Nil(t, contentTypeFields(""))
+	assert.Nil(t, contentTypeFields(" ; charset=utf-8"))
+}
+
+func TestZapLoggerIncludeResponseContentType(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		fields  map[string]interface{}
+	}{
+		{
+			name: "xml",
+			handler: func(c echo.Context) error {
+				return c.XML(http.StatusOK, struct{}{})
+			},
+			fields: map[string]interface{}{"response_content_type": "application/xml", "format": "xml"},
+		},
+		{
+			name: "json",
+			handler: func(c echo.Context) error {
+				return c.JSON(http.StatusOK, struct{}{})
+			},
+			fields: map[string]interface{}{"response_content_type": "application/json", "format": "json"},
+		},
+		{
+			name: "absent",
+			handler: func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{IncludeResponseContentType: true})(tt.handler)(c)
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, 1, logs.Len())
+			fields := logs.AllUntimed()[0].ContextMap()
+			for _, k := range []string{"response_content_type", "format"} {
+				assert.Equal(t, tt.fields[k], fields[k])
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package echozap
+
+import (
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// contextField maps an echo context key to a log field
+type contextField struct {
+	key   string
+	field string
+}
+
+// newContextFields sorts the ContextFields mapping so fields are logged in a stable order
+func newContextFields(m map[string]string) []contextField {
+	fields := make([]contextField, 0, len(m))
+	for k, f := range m {
+		fields = append(fields, contextField{key: k, field: f})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	return fields
+}
+
+// contextFieldsFields reads the mapped keys from the context, missing keys and nil values are skipped.
+// Values of any type are kept when stringify is set or an encoder is registered for them.
+func contextFieldsFields(c echo.Context, mapping []contextField, stringify bool, encoders typeEncoders) []zapcore.Field {
+	var fields []zapcore.Field
+	for _, m := range mapping {
+		v := c.Get(m.key)
+		if v == nil {
+			continue
+		}
+		if !stringify && !isScalar(v) && !encoders.has(v) {
+			continue
+		}
+		fields = append(fields, zap.Any(m.field, v))
+	}
+	return fields
+}
+
+// isScalar reports whether v is a string, a number or a boolean
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testFlags struct {
+	Beta bool
+}
+
+func TestZapLoggerContextFields(t *testing.T) {
+	for _, stringify := range []bool{false, true} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			c.Set("tenant_id", "acme")
+			c.Set("session_count", 3)
+			c.Set("feature_flags", testFlags{Beta: true})
+			c.Set("nil_value", nil)
+			return c.String(http.StatusOK, "")
+		}
+
+		obs, logs := observer.New(zap.DebugLevel)
+
+		logger := zap.New(obs)
+
+		err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+			ContextFields: map[string]string{
+				"tenant_id":     "tenant",
+				"session_count": "sessions",
+				"feature_flags": "flags",
+				"nil_value":     "nil_value",
+				"missing":       "missing",
+			},
+			ContextFieldsStringify: stringify,
+		})(h)(c)
+
+		assert.Nil(t, err)
+
+		logFields := logs.AllUntimed()[0].ContextMap()
+
+		assert.Equal(t, "acme", logFields["tenant"])
+		assert.Equal(t, int64(3), logFields["sessions"])
+		assert.NotContains(t, logFields, "nil_value")
+		assert.NotContains(t, logFields, "missing")
+
+		if stringify {
+			assert.Equal(t, testFlags{Beta: true}, logFields["flags"])
+		} else {
+			assert.NotContains(t, logFields, "flags")
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package echozap
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Reasons reported by ExplainDecisions
+const (
+	reasonSkipper       = "skipper"
+	reasonSynthetic     = "synthetic"
+	reasonHealthCheck   = "health_check"
+	reasonMinStatus     = "min_status"
+	reasonSample        = "sample"
+	reasonRateLimit     = "rate_limit"
+	reasonLogicalStatus = "logical_status"
+	reasonWriteError    = "write_error"
+	reasonSlowWrite     = "slow_write"
+	reasonLevelOverride = "level_override"
+	reasonLevelFunc     = "level_func"
+)
+
+// explainSuppressed logs at Debug level why the entry of a request was not logged
+func explainSuppressed(log *zap.Logger, req *http.Request, reason string) {
+	log.Debug("entry suppressed", zap.String("reason", reason), zap.String("request", req.Method+" "+req.RequestURI))
+}
+
+// statusReason returns the reason of the level derived from the status, e.g. status_4xx
+func statusReason(status int) string {
+	if status < 100 || status > 999 {
+		return "status_" + strconv.Itoa(status)
+	}
+	return "status_" + strconv.Itoa(status/100) + "xx"
+}
+
+// decisionField describes how the entry was leveled and sampled
+func decisionField(level zapcore.Level, reason string, verbose, policy bool) zapcore.Field {
+	s := "level=" + level.String() + " reason=" + reason + "; verbose=" + strconv.FormatBool(verbose) + "; skipper=false"
+	if policy {
+		s += "; policy=kept"
+	}
+	return zap.String("log_decision", s)
+}
@@ -0,0 +1,142 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerExplainSuppressed(t *testing.T) {
+	defer func(f func() float64) { randFloat64 = f }(randFloat64)
+	randFloat64 = func() float64 { return 0.9 }
+
+	tests := []struct {
+		name   string
+		config ZapLoggerConfig
+		path   string
+		agent  string
+		reason string
+	}{
+		{
+			name:   "skipper",
+			config: ZapLoggerConfig{Skipper: func(echo.Context) bool { return true }},
+			reason: "skipper",
+		},
+		{
+			name:   "synthetic",
+			config: ZapLoggerConfig{SyntheticUserAgents: DefaultSyntheticAgents(), SkipSynthetic: true},
+			agent:  "kube-probe/1.27",
+			reason: "synthetic",
+		},
+		{
+			name:   "health check",
+			config: ZapLoggerConfig{HealthCheckPaths: []string{"/healthz"}},
+			path:   "/healthz",
+			reason: "health_check",
+		},
+		{
+			name:   "min status",
+			config: ZapLoggerConfig{PathPolicies: []PathPolicy{{Prefix: "/", MinStatus: 400}}},
+			reason: "min_status",
+		},
+		{
+			name:   "sample",
+			config: ZapLoggerConfig{PathPolicies: []PathPolicy{{Prefix: "/", SampleRate: 0.5}}},
+			reason: "sample",
+		},
+		{
+			name:   "rate limit",
+			config: ZapLoggerConfig{PathPolicies: []PathPolicy{{Prefix: "/", RateLimit: 1}}},
+			reason: "rate_limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			tt.config.ExplainDecisions = true
+			mw, handle := ZapLoggerWithHandle(logger, tt.config)
+			defer handle.Close()
+
+			path := tt.path
+			if path == "" {
+				path = "/something"
+			}
+
+			e := echo.New()
+			// The rate limit lets the first request through
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.Header.Set("User-Agent", tt.agent)
+				c := e.NewContext(req, httptest.NewRecorder())
+
+				assert.Nil(t, mw(func(c echo.Context) error {
+					return c.NoContent(http.StatusOK)
+				})(c))
+			}
+
+			suppressed := logs.FilterMessage("entry suppressed").AllUntimed()
+			assert.NotEmpty(t, suppressed)
+			assert.Equal(t, zap.DebugLevel, suppressed[0].Level)
+			assert.Equal(t, tt.reason, suppressed[0].ContextMap()["reason"])
+			assert.Equal(t, "GET "+path, suppressed[0].ContextMap()["request"])
+		})
+	}
+}
+
+func TestZapLoggerExplainDecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   ZapLoggerConfig
+		status   int
+		decision string
+	}{
+		{
+			name:     "status",
+			status:   http.StatusNotFound,
+			decision: "level=warn reason=status_4xx; verbose=false; skipper=false",
+		},
+		{
+			name:     "verbose and policy",
+			config:   ZapLoggerConfig{VerboseSampleRate: 1, PathPolicies: []PathPolicy{{Prefix: "/"}}},
+			status:   http.StatusOK,
+			decision: "level=info reason=status_2xx; verbose=true; skipper=false; policy=kept",
+		},
+		{
+			name: "logical status",
+			config: ZapLoggerConfig{
+				LogicalStatusFunc:      func(echo.Context) (int, bool) { return 13, true },
+				LevelFromLogicalStatus: true,
+			},
+			status:   http.StatusOK,
+			decision: "level=error reason=logical_status; verbose=false; skipper=false",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			tt.config.ExplainDecisions = true
+
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/something", nil), httptest.NewRecorder())
+
+			assert.Nil(t, ZapLoggerWithConfig(logger, tt.config)(func(c echo.Context) error {
+				return c.NoContent(tt.status)
+			})(c))
+
+			assert.Equal(t, 1, logs.Len())
+			assert.Equal(t, tt.decision, logs.AllUntimed()[0].ContextMap()["log_decision"])
+		})
+	}
+}
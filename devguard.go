@@ -0,0 +1,302 @@
+package echozap
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+)
+
+// guardedContext is the echo.Context given to the hooks in development mode.
+// Echo pools its contexts, so a hook keeping it after the request completed would see another request:
+// the guard is poisoned once the request completes and any later use panics.
+type guardedContext struct {
+	echo.Context
+	done atomic.Bool
+}
+
+func (g *guardedContext) check(method string) {
+	if g.done.Load() {
+		panic(fmt.Sprintf("echozap: echo.Context.%s called after the request completed, "+
+			"hooks must not keep the context (echo reuses it for other requests)", method))
+	}
+}
+
+// poison makes any later use of the context panic
+func (g *guardedContext) poison() {
+	g.done.Store(true)
+}
+
+func (g *guardedContext) Request() *http.Request {
+	g.check("Request")
+	return g.Context.Request()
+}
+
+func (g *guardedContext) SetRequest(r *http.Request) {
+	g.check("SetRequest")
+	g.Context.SetRequest(r)
+}
+
+func (g *guardedContext) SetResponse(r *echo.Response) {
+	g.check("SetResponse")
+	g.Context.SetResponse(r)
+}
+
+func (g *guardedContext) Response() *echo.Response {
+	g.check("Response")
+	return g.Context.Response()
+}
+
+func (g *guardedContext) IsTLS() bool {
+	g.check("IsTLS")
+	return g.Context.IsTLS()
+}
+
+func (g *guardedContext) IsWebSocket() bool {
+	g.check("IsWebSocket")
+	return g.Context.IsWebSocket()
+}
+
+func (g *guardedContext) Scheme() string {
+	g.check("Scheme")
+	return g.Context.Scheme()
+}
+
+func (g *guardedContext) RealIP() string {
+	g.check("RealIP")
+	return g.Context.RealIP()
+}
+
+func (g *guardedContext) Path() string {
+	g.check("Path")
+	return g.Context.Path()
+}
+
+func (g *guardedContext) SetPath(p string) {
+	g.check("SetPath")
+	g.Context.SetPath(p)
+}
+
+func (g *guardedContext) Param(name string) string {
+	g.check("Param")
+	return g.Context.Param(name)
+}
+
+func (g *guardedContext) ParamNames() []string {
+	g.check("ParamNames")
+	return g.Context.ParamNames()
+}
+
+func (g *guardedContext) SetParamNames(names ...string) {
+	g.check("SetParamNames")
+	g.Context.SetParamNames(names...)
+}
+
+func (g *guardedContext) ParamValues() []string {
+	g.check("ParamValues")
+	return g.Context.ParamValues()
+}
+
+func (g *guardedContext) SetParamValues(values ...string) {
+	g.check("SetParamValues")
+	g.Context.SetParamValues(values...)
+}
+
+func (g *guardedContext) QueryParam(name string) string {
+	g.check("QueryParam")
+	return g.Context.QueryParam(name)
+}
+
+func (g *guardedContext) QueryParams() url.Values {
+	g.check("QueryParams")
+	return g.Context.QueryParams()
+}
+
+func (g *guardedContext) QueryString() string {
+	g.check("QueryString")
+	return g.Context.QueryString()
+}
+
+func (g *guardedContext) FormValue(name string) string {
+	g.check("FormValue")
+	return g.Context.FormValue(name)
+}
+
+func (g *guardedContext) FormParams() (url.Values, error) {
+	g.check("FormParams")
+	return g.Context.FormParams()
+}
+
+func (g *guardedContext) FormFile(name string) (*multipart.FileHeader, error) {
+	g.check("FormFile")
+	return g.Context.FormFile(name)
+}
+
+func (g *guardedContext) MultipartForm() (*multipart.Form, error) {
+	g.check("MultipartForm")
+	return g.Context.MultipartForm()
+}
+
+func (g *guardedContext) Cookie(name string) (*http.Cookie, error) {
+	g.check("Cookie")
+	return g.Context.Cookie(name)
+}
+
+func (g *guardedContext) SetCookie(cookie *http.Cookie) {
+	g.check("SetCookie")
+	g.Context.SetCookie(cookie)
+}
+
+func (g *guardedContext) Cookies() []*http.Cookie {
+	g.check("Cookies")
+	return g.Context.Cookies()
+}
+
+func (g *guardedContext) Get(key string) interface{} {
+	g.check("Get")
+	return g.Context.Get(key)
+}
+
+func (g *guardedContext) Set(key string, val interface{}) {
+	g.check("Set")
+	g.Context.Set(key, val)
+}
+
+func (g *guardedContext) Bind(i interface{}) error {
+	g.check("Bind")
+	return g.Context.Bind(i)
+}
+
+func (g *guardedContext) Validate(i interface{}) error {
+	g.check("Validate")
+	return g.Context.Validate(i)
+}
+
+func (g *guardedContext) Render(code int, name string, data interface{}) error {
+	g.check("Render")
+	return g.Context.Render(code, name, data)
+}
+
+func (g *guardedContext) HTML(code int, html string) error {
+	g.check("HTML")
+	return g.Context.HTML(code, html)
+}
+
+func (g *guardedContext) HTMLBlob(code int, b []byte) error {
+	g.check("HTMLBlob")
+	return g.Context.HTMLBlob(code, b)
+}
+
+func (g *guardedContext) String(code int, s string) error {
+	g.check("String")
+	return g.Context.String(code, s)
+}
+
+func (g *guardedContext) JSON(code int, i interface{}) error {
+	g.check("JSON")
+	return g.Context.JSON(code, i)
+}
+
+func (g *guardedContext) JSONPretty(code int, i interface{}, indent string) error {
+	g.check("JSONPretty")
+	return g.Context.JSONPretty(code, i, indent)
+}
+
+func (g *guardedContext) JSONBlob(code int, b []byte) error {
+	g.check("JSONBlob")
+	return g.Context.JSONBlob(code, b)
+}
+
+func (g *guardedContext) JSONP(code int, callback string, i interface{}) error {
+	g.check("JSONP")
+	return g.Context.JSONP(code, callback, i)
+}
+
+func (g *guardedContext) JSONPBlob(code int, callback string, b []byte) error {
+	g.check("JSONPBlob")
+	return g.Context.JSONPBlob(code, callback, b)
+}
+
+func (g *guardedContext) XML(code int, i interface{}) error {
+	g.check("XML")
+	return g.Context.XML(code, i)
+}
+
+func (g *guardedContext) XMLPretty(code int, i interface{}, indent string) error {
+	g.check("XMLPretty")
+	return g.Context.XMLPretty(code, i, indent)
+}
+
+func (g *guardedContext) XMLBlob(code int, b []byte) error {
+	g.check("XMLBlob")
+	return g.Context.XMLBlob(code, b)
+}
+
+func (g *guardedContext) Blob(code int, contentType string, b []byte) error {
+	g.check("Blob")
+	return g.Context.Blob(code, contentType, b)
+}
+
+func (g *guardedContext) Stream(code int, contentType string, r io.Reader) error {
+	g.check("Stream")
+	return g.Context.Stream(code, contentType, r)
+}
+
+func (g *guardedContext) File(file string) error {
+	g.check("File")
+	return g.Context.File(file)
+}
+
+func (g *guardedContext) Attachment(file string, name string) error {
+	g.check("Attachment")
+	return g.Context.Attachment(file, name)
+}
+
+func (g *guardedContext) Inline(file string, name string) error {
+	g.check("Inline")
+	return g.Context.Inline(file, name)
+}
+
+func (g *guardedContext) NoContent(code int) error {
+	g.check("NoContent")
+	return g.Context.NoContent(code)
+}
+
+func (g *guardedContext) Redirect(code int, location string) error {
+	g.check("Redirect")
+	return g.Context.Redirect(code, location)
+}
+
+func (g *guardedContext) Error(err error) {
+	g.check("Error")
+	g.Context.Error(err)
+}
+
+func (g *guardedContext) Handler() echo.HandlerFunc {
+	g.check("Handler")
+	return g.Context.Handler()
+}
+
+func (g *guardedContext) SetHandler(h echo.HandlerFunc) {
+	g.check("SetHandler")
+	g.Context.SetHandler(h)
+}
+
+func (g *guardedContext) Logger() echo.Logger {
+	g.check("Logger")
+	return g.Context.Logger()
+}
+
+func (g *guardedContext) Echo() *echo.Echo {
+	g.check("Echo")
+	return g.Context.Echo()
+}
+
+func (g *guardedContext) Reset(r *http.Request, w http.ResponseWriter) {
+	g.check("Reset")
+	g.Context.Reset(r, w)
+}
@@ -0,0 +1,73 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerDevelopmentGuard(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	var stashed echo.Context
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		Development: true,
+		ExpensiveFieldsFunc: func(c echo.Context) []zapcore.Field {
+			// Deliberately keep the context, using it here is fine
+			stashed = c
+			return []zapcore.Field{zap.String("path", c.Request().URL.Path)}
+		},
+	})
+
+	assert.NotPanics(t, func() { assert.Nil(t, mw(h)(c)) })
+	assert.Equal(t, "/something", logs.AllUntimed()[0].ContextMap()["path"])
+
+	assert.PanicsWithValue(t,
+		"echozap: echo.Context.Request called after the request completed, hooks must not keep the context (echo reuses it for other requests)",
+		func() { stashed.Request() },
+	)
+	assert.Panics(t, func() { stashed.Get("key") })
+}
+
+func TestZapLoggerWithoutDevelopmentGuard(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, _ := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	var stashed echo.Context
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		ExpensiveFieldsFunc: func(c echo.Context) []zapcore.Field {
+			stashed = c
+			return nil
+		},
+	})
+
+	assert.Nil(t, mw(h)(c))
+	assert.True(t, c == stashed)
+}
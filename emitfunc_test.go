@@ -0,0 +1,61 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerEmitFunc(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusNotFound, "")
+	}
+
+	obs, logs := observer.New(zap.ErrorLevel)
+
+	logger := zap.New(obs)
+
+	var calls int
+	var gotLog *zap.Logger
+	var gotLevel zapcore.Level
+	var gotMsg string
+	var gotFields []zapcore.Field
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		IncludeRequestLogMessage: true,
+		ExpensiveFieldsFunc: func(echo.Context) []zapcore.Field {
+			return []zapcore.Field{zap.String("expensive", "computed")}
+		},
+		EmitFunc: func(log *zap.Logger, level zapcore.Level, msg string, fields []zapcore.Field) {
+			calls++
+			gotLog, gotLevel, gotMsg, gotFields = log, level, msg, fields
+		},
+	})(h)(c)
+
+	assert.Nil(t, err)
+
+	// The entry is below the level of the logger, the decision is left to EmitFunc
+	assert.Equal(t, 0, logs.Len())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, logger, gotLog)
+	assert.Equal(t, zapcore.WarnLevel, gotLevel)
+	assert.Equal(t, "Client error: GET /something", gotMsg)
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range gotFields {
+		f.AddTo(enc)
+	}
+	assert.Equal(t, int64(http.StatusNotFound), enc.Fields["status"])
+	assert.Equal(t, "computed", enc.Fields["expensive"])
+}
@@ -0,0 +1,76 @@
+package echozap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Values are the measurements of a request, computed once after the handler returned
+// and handed to the hooks and the emitters
+type Values struct {
+	Start     time.Time
+	Latency   time.Duration
+	Status    int
+	Size      int64
+	RequestID string
+	Method    string
+	URI       string
+	Path      string
+	Host      string
+	RemoteIP  string
+	UserAgent string
+	Err       error
+	// Level and message of the entry, final once LevelFunc and MessageFunc ran
+	Level   zapcore.Level
+	Message string
+	// Fields of the zap entry, including the optional ones. Only set for FieldsFunc and the emitters
+	Fields []zapcore.Field
+}
+
+// Emitter exports the access log entries to another destination than the zap logger
+type Emitter interface {
+	Emit(c echo.Context, v Values) error
+}
+
+// Sink is an Emitter replacing the zap logger of the middleware, see ZapLoggerConfig.Sink
+type Sink interface {
+	Emitter
+	// Enabled reports whether entries at the level are written. The expensive fields are only computed for enabled entries
+	Enabled(c echo.Context, level zapcore.Level) bool
+}
+
+// EmitterFunc is an adapter to use a function as an Emitter
+type EmitterFunc func(c echo.Context, v Values) error
+
+// Emit calls f(c, v)
+func (f EmitterFunc) Emit(c echo.Context, v Values) error {
+	return f(c, v)
+}
+
+// emit hands the values to each emitter in order. A failing or panicking emitter is reported on log
+// and does not prevent the next ones from running.
+func emit(log *zap.Logger, emitters []Emitter, c echo.Context, v Values) {
+	for _, e := range emitters {
+		if err := safeEmit(e, c, v); err != nil {
+			emitFailed(log, e, err)
+		}
+	}
+}
+
+// emitFailed reports the failure of an emitter on log
+func emitFailed(log *zap.Logger, e Emitter, err error) {
+	log.Error("echozap: emitter failed", zap.Error(err), zap.String("emitter", fmt.Sprintf("%T", e)))
+}
+
+func safeEmit(e Emitter, c echo.Context, v Values) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return e.Emit(c, v)
+}
@@ -0,0 +1,99 @@
+package echozap
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerEmitters(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something?q=1", nil)
+	req.Header.Set(echo.HeaderXRequestID, "abc-123")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusTeapot, "short and stout")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	var order []string
+	var captured []Values
+	capture := func(name string) Emitter {
+		return EmitterFunc(func(c echo.Context, v Values) error {
+			order = append(order, name)
+			captured = append(captured, v)
+			return nil
+		})
+	}
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		Emitters: []Emitter{
+			capture("first"),
+			EmitterFunc(func(echo.Context, Values) error { panic("boom") }),
+			EmitterFunc(func(echo.Context, Values) error { return errors.New("unavailable") }),
+			capture("last"),
+		},
+	})(h)(c)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"first", "last"}, order)
+	assert.Equal(t, captured[0].Fields, captured[1].Fields)
+
+	v := captured[0]
+	assert.Equal(t, http.StatusTeapot, v.Status)
+	assert.Equal(t, int64(len("short and stout")), v.Size)
+	assert.Equal(t, "abc-123", v.RequestID)
+	assert.Equal(t, http.MethodGet, v.Method)
+	assert.Equal(t, "/something?q=1", v.URI)
+	assert.Equal(t, "/something", v.Path)
+	assert.Equal(t, "example.com", v.Host)
+	assert.Equal(t, "192.0.2.1", v.RemoteIP)
+	assert.Equal(t, zapcore.WarnLevel, v.Level)
+	assert.Equal(t, "Client error", v.Message)
+	assert.True(t, v.Latency > 0)
+
+	assert.Equal(t, 3, logs.Len())
+	assert.Equal(t, "Client error", logs.AllUntimed()[0].Message)
+	assert.Equal(t, v.Fields, logs.AllUntimed()[0].Context)
+
+	failures := logs.FilterMessage("echozap: emitter failed").AllUntimed()
+	assert.Equal(t, 2, len(failures))
+	assert.Equal(t, "panic: boom", failures[0].ContextMap()["error"])
+	assert.Equal(t, "unavailable", failures[1].ContextMap()["error"])
+}
+
+func TestZapLoggerEmittersOnly(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	var captured []Values
+	err := ZapLoggerWithConfig(zap.NewNop(), ZapLoggerConfig{
+		Emitters: []Emitter{EmitterFunc(func(c echo.Context, v Values) error {
+			captured = append(captured, v)
+			return nil
+		})},
+	})(h)(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(captured))
+	assert.Equal(t, "Success", captured[0].Message)
+}
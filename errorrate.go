@@ -0,0 +1,92 @@
+package echozap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ErrorRateConfig defines the adaptive mode, where entries carry more context while the server error rate is high
+type ErrorRateConfig struct {
+	// Rate of server errors (0 to 1) over the window above which the adaptive mode is active. Zero disables it
+	Threshold float64
+	// Duration of the sliding window. Defaults to 10s
+	Window time.Duration
+	// Number of buckets the window is split into. Defaults to 10
+	Buckets int
+	// Minimum number of requests in the window for the adaptive mode to become active
+	MinRequests int64
+	// Whether entries are logged with the verbose field set while the adaptive mode is active
+	Verbose bool
+}
+
+// errorRate counts the requests and server errors over a sliding window.
+// Buckets are reset lock-free when reused, so counts may be slightly off under contention.
+type errorRate struct {
+	config  ErrorRateConfig
+	width   int64
+	buckets []errorRateBucket
+	now     func() time.Time
+}
+
+type errorRateBucket struct {
+	epoch  atomic.Int64
+	total  atomic.Int64
+	errors atomic.Int64
+}
+
+// withDefaults returns the configuration with the defaults of Window and Buckets
+func (config ErrorRateConfig) withDefaults() ErrorRateConfig {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.Buckets <= 0 {
+		config.Buckets = 10
+	}
+	return config
+}
+
+func newErrorRate(config ErrorRateConfig) *errorRate {
+	config = config.withDefaults()
+	return &errorRate{
+		config:  config,
+		width:   int64(config.Window) / int64(config.Buckets),
+		buckets: make([]errorRateBucket, config.Buckets),
+		now:     time.Now,
+	}
+}
+
+func (r *errorRate) record(serverError bool) {
+	epoch := r.now().UnixNano() / r.width
+	b := &r.buckets[epoch%int64(len(r.buckets))]
+
+	if old := b.epoch.Load(); old != epoch && b.epoch.CompareAndSwap(old, epoch) {
+		b.total.Store(0)
+		b.errors.Store(0)
+	}
+
+	b.total.Add(1)
+	if serverError {
+		b.errors.Add(1)
+	}
+}
+
+// active returns the error rate over the window and whether it is above the threshold
+func (r *errorRate) active() (float64, bool) {
+	epoch := r.now().UnixNano() / r.width
+
+	var total, errors int64
+	for i := range r.buckets {
+		b := &r.buckets[i]
+		if epoch-b.epoch.Load() < int64(len(r.buckets)) {
+			total += b.total.Load()
+			errors += b.errors.Load()
+		}
+	}
+
+	if total == 0 || total < r.config.MinRequests {
+		return 0, false
+	}
+
+	rate := float64(errors) / float64(total)
+	return rate, rate > r.config.Threshold
+}
@@ -0,0 +1,74 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerErrorRate(t *testing.T) {
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{
+		ErrorRate: ErrorRateConfig{
+			Threshold:   0.5,
+			Window:      10 * time.Second,
+			MinRequests: 4,
+			Verbose:     true,
+		},
+		VerboseFields: VerboseFieldsConfig{Headers: true},
+	})
+
+	clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	handle.errorRate.now = func() time.Time { return clock }
+
+	serve := func(status int) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			return c.NoContent(status)
+		}
+
+		assert.Nil(t, mw(h)(c))
+
+		entries := logs.AllUntimed()
+		return entries[len(entries)-1].ContextMap()
+	}
+
+	// Not enough requests yet
+	assert.NotContains(t, serve(http.StatusInternalServerError), "error_rate")
+
+	for i := 0; i < 3; i++ {
+		clock = clock.Add(time.Second)
+		assert.NotContains(t, serve(http.StatusOK), "error_rate")
+	}
+
+	// 3 errors out of 6 requests is not above the threshold
+	serve(http.StatusInternalServerError)
+	serve(http.StatusInternalServerError)
+	assert.NotContains(t, serve(http.StatusBadGateway), "error_rate")
+
+	// 4 errors out of 7 requests is
+	active := serve(http.StatusOK)
+	assert.Equal(t, 4.0/7.0, active["error_rate"])
+	assert.Equal(t, true, active["verbose_sample"])
+	assert.NotNil(t, active["request_headers"])
+
+	// The burst leaves the window
+	clock = clock.Add(10 * time.Second)
+	recovered := serve(http.StatusOK)
+	assert.NotContains(t, recovered, "error_rate")
+	assert.NotContains(t, recovered, "verbose_sample")
+}
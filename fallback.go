@@ -0,0 +1,125 @@
+package echozap
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// withFallback returns log writing its entries to fallback, with fallback=true, when its core fails to write them,
+// along with the counter of these fallback emissions
+func withFallback(log, fallback *zap.Logger) (*zap.Logger, *atomic.Uint64) {
+	count := new(atomic.Uint64)
+	log = log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &fallbackCore{Core: core, fallback: fallback.Core(), count: count}
+	}))
+	return log, count
+}
+
+// fallbackCore checks the result of the writes of the wrapped core
+type fallbackCore struct {
+	zapcore.Core
+	fallback zapcore.Core
+	count    *atomic.Uint64
+}
+
+func (c *fallbackCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fallbackCore{Core: c.Core.With(fields), fallback: c.fallback.With(fields), count: c.count}
+}
+
+// Check leaves the decision to the wrapped core, so each core of a tee keeps its own level and sampling, and registers
+// the resulting entry so its write failures are detected
+func (c *fallbackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked := c.Core.Check(ent, nil)
+	if checked == nil {
+		return ce
+	}
+	return ce.AddCore(ent, &checkedWrite{fallbackCore: c, checked: checked})
+}
+
+func (c *fallbackCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.writeFallback(ent, fields, c.Core.Write(ent, fields))
+}
+
+// writeFallback writes the entry to the fallback core when err reports the failure of the primary write
+func (c *fallbackCore) writeFallback(ent zapcore.Entry, fields []zapcore.Field, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, f := range fields {
+		if lost, ok := f.Interface.(*lostEntry); ok && f.Type == zapcore.SkipType {
+			lost.failed = true
+			fields = lost.fields
+			break
+		}
+	}
+
+	c.count.Add(1)
+	if ferr := c.fallback.Write(ent, append(fields[:len(fields):len(fields)], zap.Bool("fallback", true))); ferr != nil {
+		return errors.Join(err, ferr)
+	}
+	// The primary failure is still reported to the error output of the logger
+	return err
+}
+
+// lostEntry rides along a compacted entry in a skipped field, so the fallback core writes the complete entry,
+// which the fallback output can read without its base, and the compactor learns that the entry was lost
+type lostEntry struct {
+	fields []zapcore.Field
+	failed bool
+}
+
+func (e *lostEntry) field() zapcore.Field {
+	return zapcore.Field{Type: zapcore.SkipType, Interface: e}
+}
+
+// checkedWrite writes an entry checked by the wrapped core to the cores that accepted it
+type checkedWrite struct {
+	*fallbackCore
+	checked *zapcore.CheckedEntry
+}
+
+// Write writes the checked entry. A CheckedEntry only reports the failures of its cores to its error output,
+// so it is captured to tell whether the entry was lost.
+func (w *checkedWrite) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var failure writeFailure
+	w.checked.ErrorOutput = &failure
+	w.checked.Write(fields...)
+	return w.writeFallback(ent, fields, failure.err())
+}
+
+// writeFailure is the error output of a CheckedEntry, which reports the write failures as "<time> write error: <err>"
+type writeFailure struct {
+	msg []byte
+}
+
+func (f *writeFailure) Write(p []byte) (int, error) {
+	f.msg = append(f.msg, p...)
+	return len(p), nil
+}
+
+func (f *writeFailure) Sync() error {
+	return nil
+}
+
+func (f *writeFailure) err() error {
+	if len(f.msg) == 0 {
+		return nil
+	}
+	msg := strings.TrimSpace(string(f.msg))
+	if _, cause, ok := strings.Cut(msg, "write error: "); ok {
+		msg = cause
+	}
+	return errors.New(msg)
+}
+
+// writeSinkFallback writes the entry a Sink failed to write to fallback, and returns the errors to report
+func writeSinkFallback(fallback *zap.Logger, count *atomic.Uint64, v Values, fields []zapcore.Field, err error) error {
+	c := &fallbackCore{fallback: fallback.Core(), count: count}
+	ent := zapcore.Entry{Level: v.Level, Time: v.Start.Add(v.Latency), Message: v.Message}
+	return c.writeFallback(ent, fields, err)
+}
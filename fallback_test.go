@@ -0,0 +1,178 @@
+package echozap
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// flakySyncer fails the writes while down is set
+type flakySyncer struct {
+	down   bool
+	writes int
+	out    bytes.Buffer
+}
+
+func (s *flakySyncer) Write(p []byte) (int, error) {
+	if s.down {
+		return 0, errors.New("syslog unreachable")
+	}
+	s.writes++
+	return s.out.Write(p)
+}
+
+func (s *flakySyncer) Sync() error {
+	return nil
+}
+
+func TestZapLoggerFallbackLogger(t *testing.T) {
+	syncer := &flakySyncer{}
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), syncer, zap.InfoLevel),
+		zap.ErrorOutput(zapcore.AddSync(&flakySyncer{})))
+
+	obs, fallback := observer.New(zap.DebugLevel)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{FallbackLogger: zap.New(obs)})
+
+	e := echo.New()
+	serve := func(path string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Nil(t, mw(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})(c))
+	}
+
+	serve("/up")
+	syncer.down = true
+	serve("/down")
+	syncer.down = false
+	serve("/up-again")
+
+	assert.Equal(t, 2, syncer.writes)
+
+	assert.Equal(t, 1, fallback.Len())
+	entry := fallback.AllUntimed()[0]
+	assert.Equal(t, "Success", entry.Message)
+	assert.Equal(t, "GET /down", entry.ContextMap()["request"])
+	assert.Equal(t, true, entry.ContextMap()["fallback"])
+
+	assert.Equal(t, uint64(1), handle.Stats().Fallbacks)
+}
+
+func TestZapLoggerFallbackLoggerCompactRepeats(t *testing.T) {
+	syncer := &flakySyncer{}
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), syncer, zap.InfoLevel),
+		zap.ErrorOutput(zapcore.AddSync(&flakySyncer{})))
+
+	obs, fallback := observer.New(zap.DebugLevel)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{CompactRepeats: true, FallbackLogger: zap.New(obs)})
+
+	e := echo.New()
+	serve := func(path string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("User-Agent", "sdk")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Nil(t, mw(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})(c))
+	}
+
+	// The first base and a reference to the second one are lost
+	syncer.down = true
+	serve("/base-lost")
+	syncer.down = false
+	serve("/base")
+	syncer.down = true
+	serve("/reference-lost")
+	syncer.down = false
+	serve("/reference")
+
+	// The fallback entries are complete
+	assert.Equal(t, 2, fallback.Len())
+	for i, path := range []string{"/base-lost", "/reference-lost"} {
+		fields := fallback.AllUntimed()[i].ContextMap()
+		assert.Equal(t, "GET "+path, fields["request"])
+		assert.Equal(t, "example.com", fields["host"])
+		assert.Equal(t, "sdk", fields["user_agent"])
+		assert.Equal(t, true, fields["fallback"])
+		assert.NotContains(t, fields, "repeat_base")
+		assert.NotContains(t, fields, "repeat_of")
+	}
+	assert.Equal(t, uint64(2), handle.Stats().Fallbacks)
+
+	// The primary output starts with a new base, so it decodes in one pass
+	entries := decodeLines(t, &syncer.out)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, float64(2), entries[0]["repeat_base"])
+	var d RepeatDecoder
+	for i, path := range []string{"/base", "/reference"} {
+		assert.Nil(t, d.Decode(entries[i]))
+		assert.Equal(t, "GET "+path, entries[i]["request"])
+		assert.Equal(t, "example.com", entries[i]["host"])
+		assert.Equal(t, "sdk", entries[i]["user_agent"])
+	}
+}
+
+func TestFallbackCoreRespectsLevel(t *testing.T) {
+	syncer := &flakySyncer{down: true}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), syncer, zap.WarnLevel)
+
+	obs, fallback := observer.New(zap.DebugLevel)
+
+	logger, count := withFallback(zap.New(core, zap.ErrorOutput(zapcore.AddSync(&flakySyncer{}))), zap.New(obs))
+	logger.Info("filtered")
+	logger.With(zap.String("scope", "test")).Warn("kept")
+
+	assert.Equal(t, uint64(1), count.Load())
+	assert.Equal(t, 1, fallback.Len())
+	assert.Equal(t, "test", fallback.AllUntimed()[0].ContextMap()["scope"])
+}
+
+func TestFallbackCoreTeeLevels(t *testing.T) {
+	syncer := &flakySyncer{down: true}
+	errorsObs, errorLogs := observer.New(zap.ErrorLevel)
+	tee := zapcore.NewTee(
+		errorsObs,
+		zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), syncer, zap.InfoLevel),
+	)
+
+	obs, fallback := observer.New(zap.DebugLevel)
+
+	logger, count := withFallback(zap.New(tee, zap.ErrorOutput(zapcore.AddSync(&flakySyncer{}))), zap.New(obs))
+	logger.Debug("filtered")
+	logger.Info("info")
+	syncer.down = false
+	logger.Error("error")
+
+	// Each core of the tee keeps its own level
+	assert.Equal(t, 1, errorLogs.Len())
+	assert.Equal(t, "error", errorLogs.AllUntimed()[0].Message)
+	assert.Equal(t, 1, syncer.writes)
+
+	assert.Equal(t, uint64(1), count.Load())
+	assert.Equal(t, 1, fallback.Len())
+	assert.Equal(t, "info", fallback.AllUntimed()[0].Message)
+	assert.Equal(t, true, fallback.AllUntimed()[0].ContextMap()["fallback"])
+}
+
+func TestWriteFailure(t *testing.T) {
+	var f writeFailure
+	assert.Nil(t, f.err())
+
+	_, _ = f.Write([]byte("2020-01-01 00:00:00 +0000 UTC write error: syslog unreachable\n"))
+	assert.EqualError(t, f.err(), "syslog unreachable")
+}
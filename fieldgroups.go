@@ -0,0 +1,90 @@
+package echozap
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// fieldGroups nests the built-in fields in named objects
+type fieldGroups struct {
+	// names of the groups, sorted so the layout does not depend on the map order
+	names []string
+	// group indexes names by field key
+	group map[string]int
+}
+
+// newFieldGroups compiles the FieldGroups option, failing on unknown field names and on group names
+// clashing with a built-in field
+func newFieldGroups(groups map[string]string) (*fieldGroups, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(groups))
+	seen := make(map[string]bool, len(groups))
+	for field, name := range groups {
+		if _, err := lookupBuiltinField("FieldGroups", field); err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return nil, fmt.Errorf("echozap: empty group name for field %q in FieldGroups", field)
+		}
+		if _, err := lookupBuiltinField("FieldGroups", name); err == nil {
+			return nil, fmt.Errorf("echozap: group name %q in FieldGroups is a field name", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+	g := &fieldGroups{names: names, group: make(map[string]int, len(groups))}
+	for field, name := range groups {
+		g.group[field] = index[name]
+	}
+	return g, nil
+}
+
+// apply moves the grouped fields to an object per group, after the other fields and in the order of the group names.
+// The fields keep their order within the groups.
+func (g *fieldGroups) apply(fields []zapcore.Field) []zapcore.Field {
+	buckets := make([]groupMarshaler, len(g.names))
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if i, ok := g.group[f.Key]; ok && f.Type != zapcore.SkipType {
+			buckets[i] = append(buckets[i], f)
+			continue
+		}
+		out = append(out, f)
+	}
+
+	for i, bucket := range buckets {
+		if len(bucket) > 0 {
+			out = append(out, zap.Object(g.names[i], bucket))
+		}
+	}
+	return out
+}
+
+// groupMarshaler logs fields as an object
+type groupMarshaler []zapcore.Field
+
+// Fields returns the grouped fields, so emitters can convert them by type
+func (m groupMarshaler) Fields() []zapcore.Field {
+	return m
+}
+
+func (m groupMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range m {
+		f.AddTo(enc)
+	}
+	return nil
+}
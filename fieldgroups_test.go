@@ -0,0 +1,59 @@
+package echozap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewFieldGroups(t *testing.T) {
+	g, err := newFieldGroups(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, g)
+
+	_, err = newFieldGroups(map[string]string{"remote_addr": "net"})
+	assert.EqualError(t, err, `echozap: unknown field "remote_addr" in FieldGroups, valid names are: remote_ip, latency, host, request, status, size, user_agent, request_id, error`)
+
+	_, err = newFieldGroups(map[string]string{"status": ""})
+	assert.EqualError(t, err, `echozap: empty group name for field "status" in FieldGroups`)
+
+	_, err = newFieldGroups(map[string]string{"status": "request"})
+	assert.EqualError(t, err, `echozap: group name "request" in FieldGroups is a field name`)
+}
+
+func TestFieldGroupsApply(t *testing.T) {
+	g, err := newFieldGroups(map[string]string{"remote_ip": "net", "user_agent": "net", "status": "http", "latency": "http"})
+	assert.Nil(t, err)
+
+	fields := []zapcore.Field{
+		zap.String("remote_ip", "192.0.2.1"),
+		zap.String("latency", "1ms"),
+		zap.String("host", "example.com"),
+		zap.Int("status", 200),
+		zap.Skip(),
+		zap.String("user_agent", "curl"),
+		zap.String("tenant", "acme"),
+	}
+
+	for i := 0; i < 10; i++ {
+		grouped := g.apply(fields)
+
+		var keys []string
+		for _, f := range grouped {
+			keys = append(keys, f.Key)
+		}
+		assert.Equal(t, []string{"host", "", "tenant", "http", "net"}, keys)
+
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range grouped {
+			f.AddTo(enc)
+		}
+		assert.Equal(t, map[string]interface{}{"latency": "1ms", "status": int64(200)}, enc.Fields["http"])
+		assert.Equal(t, map[string]interface{}{"remote_ip": "192.0.2.1", "user_agent": "curl"}, enc.Fields["net"])
+	}
+
+	// Groups without fields are omitted
+	assert.Equal(t, fields[2:3], g.apply(fields[2:3]))
+}
@@ -0,0 +1,88 @@
+package echozap
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// builtinField indexes the always present fields, in the order they are logged
+type builtinField uint
+
+const (
+	fieldRemoteIP builtinField = iota
+	fieldLatency
+	fieldHost
+	fieldRequest
+	fieldStatus
+	fieldSize
+	fieldUserAgent
+	fieldRequestID
+	fieldError
+	builtinFieldCount
+)
+
+// name returns the name of the field, see fieldRegistry
+func (f builtinField) name() string {
+	return fieldRegistry[f].name
+}
+
+// fieldMask is the set of built-in fields to emit
+type fieldMask uint32
+
+const allFields fieldMask = 1<<builtinFieldCount - 1
+
+func (m fieldMask) has(f builtinField) bool {
+	return m&(1<<f) != 0
+}
+
+// filter removes the fields not in the mask from fields, which holds the built-in fields in order
+func (m fieldMask) filter(fields []zapcore.Field) []zapcore.Field {
+	if m == allFields {
+		return fields
+	}
+
+	kept := fields[:0]
+	for i, f := range fields {
+		if m.has(builtinField(i)) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// newFieldMask compiles the OnlyFields and ExcludeFields options, failing on unknown field names
+func newFieldMask(only, exclude []string) (fieldMask, error) {
+	m := allFields
+	if len(only) > 0 {
+		m = 0
+		for _, name := range only {
+			f, err := lookupBuiltinField("OnlyFields", name)
+			if err != nil {
+				return 0, err
+			}
+			m |= 1 << f
+		}
+	}
+	for _, name := range exclude {
+		f, err := lookupBuiltinField("ExcludeFields", name)
+		if err != nil {
+			return 0, err
+		}
+		m &^= 1 << f
+	}
+	return m, nil
+}
+
+func lookupBuiltinField(option, name string) (builtinField, error) {
+	names := make([]string, 0, builtinFieldCount)
+	for f := builtinField(0); f < builtinFieldCount; f++ {
+		if f.name() == name {
+			return f, nil
+		}
+		names = append(names, f.name())
+	}
+	return 0, fmt.Errorf("echozap: unknown field %q in %s, valid names are: %s",
+		name, option, strings.Join(names, ", "))
+}
@@ -0,0 +1,73 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerFieldFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ZapLoggerConfig
+		keys   []string
+	}{
+		{
+			name:   "only",
+			config: ZapLoggerConfig{OnlyFields: []string{"status", "error", "latency"}},
+			keys:   []string{"error", "latency", "status", "custom"},
+		},
+		{
+			name:   "exclude",
+			config: ZapLoggerConfig{ExcludeFields: []string{"user_agent", "remote_ip", "error"}},
+			keys:   []string{"latency", "host", "request", "status", "size", "request_id", "custom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return echo.ErrNotFound
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			tt.config.ExpensiveFieldsFunc = func(echo.Context) []zapcore.Field {
+				return []zapcore.Field{zap.String("custom", "value")}
+			}
+
+			err := ZapLoggerWithConfig(logger, tt.config)(h)(c)
+
+			assert.Nil(t, err)
+
+			var keys []string
+			for _, f := range logs.AllUntimed()[0].Context {
+				keys = append(keys, f.Key)
+			}
+			assert.Equal(t, tt.keys, keys)
+		})
+	}
+}
+
+func TestZapLoggerFieldFiltersValidation(t *testing.T) {
+	logger := zap.NewNop()
+
+	assert.PanicsWithError(t,
+		`echozap: unknown field "stauts" in OnlyFields, valid names are: remote_ip, latency, host, request, status, size, user_agent, request_id, error`,
+		func() { ZapLoggerWithConfig(logger, ZapLoggerConfig{OnlyFields: []string{"stauts"}}) },
+	)
+	assert.Panics(t, func() { ZapLoggerWithConfig(logger, ZapLoggerConfig{ExcludeFields: []string{"ip"}}) })
+}
@@ -0,0 +1,98 @@
+package echozap
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// genericErrorTypes tell nothing about the error, their message is used for the fingerprint instead
+var genericErrorTypes = map[string]bool{
+	"*errors.errorString": true,
+	"*fmt.wrapError":      true,
+	"*fmt.wrapErrors":     true,
+	"*errors.joinError":   true,
+}
+
+// errorFingerprintFields returns a stable fingerprint grouping similar errors, and the type of the deepest error.
+// The fingerprint is built from the chain of error types, or from the message with its variable parts (digits and
+// hexadecimal runs) normalized when the chain only contains generic errors.
+func errorFingerprintFields(err error) []zapcore.Field {
+	var (
+		types []string
+		first error
+		last  error
+	)
+
+	for err != nil {
+		if he, ok := err.(*echo.HTTPError); ok {
+			if he.Internal == nil && first == nil {
+				first, last = errors.New(fmt.Sprint(he.Message)), he
+			}
+			err = he.Internal
+			continue
+		}
+
+		if first == nil {
+			first = err
+		}
+		last = err
+
+		if t := fmt.Sprintf("%T", err); !genericErrorTypes[t] {
+			types = append(types, t)
+		}
+		err = errors.Unwrap(err)
+	}
+
+	if first == nil {
+		return nil
+	}
+
+	key := strings.Join(types, ">")
+	if key == "" {
+		key = normalizeErrorMessage(first.Error())
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return []zapcore.Field{
+		zap.String("error_fingerprint", fmt.Sprintf("%08x", h.Sum32())),
+		zap.String("error_type", fmt.Sprintf("%T", last)),
+	}
+}
+
+// normalizeErrorMessage replaces the runs of hexadecimal characters containing a digit with '#'
+func normalizeErrorMessage(msg string) string {
+	var b strings.Builder
+	b.Grow(len(msg))
+
+	for i := 0; i < len(msg); {
+		j, digit := i, false
+		for j < len(msg) && isHex(msg[j]) {
+			digit = digit || msg[j] >= '0' && msg[j] <= '9'
+			j++
+		}
+
+		switch {
+		case j == i:
+			b.WriteByte(msg[i])
+			j++
+		case digit:
+			b.WriteByte('#')
+		default:
+			b.WriteString(msg[i:j])
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,61 @@
+package echozap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	assert.Equal(t, "user # not found at #x#", normalizeErrorMessage("user 1234 not found at 0xc000a1f0"))
+	assert.Equal(t, "order #-#-#-#-# failed: bad face", normalizeErrorMessage("order 8e03978e-40d5-43e8-bc93-6894a57f9324 failed: bad face"))
+}
+
+func TestZapLoggerErrorFingerprint(t *testing.T) {
+	fingerprint := func(handlerErr error) map[string]interface{} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			return handlerErr
+		}
+
+		obs, logs := observer.New(zap.DebugLevel)
+
+		logger := zap.New(obs)
+
+		err := ZapLoggerWithConfig(logger, ZapLoggerConfig{ErrorFingerprint: true})(h)(c)
+
+		assert.Nil(t, err)
+
+		return logs.AllUntimed()[0].ContextMap()
+	}
+
+	user1 := fingerprint(fmt.Errorf("loading profile: %w", errors.New("user 1234 not found")))
+	user2 := fingerprint(fmt.Errorf("loading profile: %w", errors.New("user 987 not found")))
+	other := fingerprint(errors.New("connection refused"))
+	path1 := fingerprint(echo.NewHTTPError(http.StatusInternalServerError).SetInternal(&os.PathError{Op: "open", Path: "/a", Err: os.ErrNotExist}))
+	path2 := fingerprint(&os.PathError{Op: "read", Path: "/b", Err: os.ErrNotExist})
+
+	assert.Len(t, user1["error_fingerprint"], 8)
+	assert.Equal(t, user1["error_fingerprint"], user2["error_fingerprint"])
+	assert.NotEqual(t, user1["error_fingerprint"], other["error_fingerprint"])
+	assert.Equal(t, "*errors.errorString", user1["error_type"])
+
+	assert.Equal(t, path1["error_fingerprint"], path2["error_fingerprint"])
+	assert.NotEqual(t, path1["error_fingerprint"], user1["error_fingerprint"])
+	assert.Equal(t, "*errors.errorString", path1["error_type"])
+
+	notFound := fingerprint(echo.ErrNotFound)
+	assert.NotContains(t, notFound, "error_fingerprint")
+}
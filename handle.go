@@ -0,0 +1,133 @@
+package echozap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Handle controls the lifecycle of a ZapLogger middleware instance
+type Handle struct {
+	health      *healthSummary
+	sampleStats *SampleStats
+	fallbacks   *atomic.Uint64
+	errorRate   *errorRate
+	summary     *latencySummary
+	watchlist   Watchlist
+	// now returns times with a monotonic reading, so latencies are not affected by wall clock steps
+	now func() time.Time
+
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// clockKey is the context key holding the clock of the middleware, so MarkHandler and StartPhase time the request
+// like its latency
+const clockKey = "echozap.clock"
+
+// requestClock returns the clock of the middleware serving the request, or time.Now outside of it
+func requestClock(c echo.Context) func() time.Time {
+	if now, ok := c.Get(clockKey).(func() time.Time); ok {
+		return now
+	}
+	return time.Now
+}
+
+// newTicker is replaced in tests to drive the background work
+var newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+func newHandle(log *zap.Logger, config ZapLoggerConfig) *Handle {
+	h := &Handle{
+		stop:        make(chan struct{}),
+		sampleStats: config.SampleStats,
+		now:         time.Now,
+	}
+
+	if config.ErrorRate.Threshold > 0 {
+		h.errorRate = newErrorRate(config.ErrorRate)
+	}
+
+	if len(config.HealthCheckPaths) > 0 {
+		h.health = newHealthSummary(log, config.HealthCheckPaths)
+		h.every(config.HealthCheckSummaryInterval, h.health.flush)
+	}
+
+	if config.SummaryInterval > 0 {
+		h.summary = newLatencySummary(log, h.now())
+		h.every(config.SummaryInterval, func() { h.summary.flush(h.now()) })
+	}
+
+	return h
+}
+
+// every runs f on each tick of a ticker with the given interval until the handle is closed
+func (h *Handle) every(d time.Duration, f func()) {
+	tick, stopTicker := newTicker(d)
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer stopTicker()
+
+		for {
+			select {
+			case <-tick:
+				f()
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Watchlist returns the rules selecting the requests logged to the WatchLogger
+func (h *Handle) Watchlist() *Watchlist {
+	return &h.watchlist
+}
+
+// Stats is a snapshot of the middleware counters
+type Stats struct {
+	// Number of entries kept by the sampler of the logger returned by WrapWithSampling
+	Sampled uint64
+	// Number of entries dropped by the sampler of the logger returned by WrapWithSampling
+	Dropped uint64
+	// Number of entries written to the FallbackLogger
+	Fallbacks uint64
+}
+
+// Stats returns a snapshot of the middleware counters
+func (h *Handle) Stats() Stats {
+	var s Stats
+	if h.sampleStats != nil {
+		s.Sampled = h.sampleStats.Sampled()
+		s.Dropped = h.sampleStats.Dropped()
+	}
+	if h.fallbacks != nil {
+		s.Fallbacks = h.fallbacks.Load()
+	}
+	return s
+}
+
+// Close stops the background work of the middleware and flushes pending summaries.
+// It is safe to call Close more than once.
+func (h *Handle) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.stop)
+		h.wg.Wait()
+
+		if h.health != nil {
+			h.health.flush()
+		}
+		if h.summary != nil {
+			h.summary.flush(h.now())
+		}
+	})
+	return nil
+}
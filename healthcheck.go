@@ -0,0 +1,81 @@
+package echozap
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// healthSummary accumulates the hits on health check paths between two summaries
+type healthSummary struct {
+	log   *zap.Logger
+	paths []string
+	stats map[string]*healthStats
+}
+
+type healthStats struct {
+	hits       atomic.Int64
+	failures   atomic.Int64
+	maxLatency atomic.Int64
+}
+
+func newHealthSummary(log *zap.Logger, paths []string) *healthSummary {
+	s := &healthSummary{
+		log:   log,
+		stats: make(map[string]*healthStats, len(paths)),
+	}
+	for _, p := range paths {
+		if _, ok := s.stats[p]; !ok {
+			s.paths = append(s.paths, p)
+			s.stats[p] = &healthStats{}
+		}
+	}
+	return s
+}
+
+// record counts a request on a health check path and reports whether it was summarized.
+// Failed requests are counted but must still be logged by the caller.
+func (s *healthSummary) record(path string, latency time.Duration, status int) bool {
+	stats, ok := s.stats[path]
+	if !ok {
+		return false
+	}
+
+	stats.hits.Add(1)
+	for {
+		max := stats.maxLatency.Load()
+		if int64(latency) <= max || stats.maxLatency.CompareAndSwap(max, int64(latency)) {
+			break
+		}
+	}
+
+	if status < 200 || status >= 300 {
+		stats.failures.Add(1)
+		return false
+	}
+	return true
+}
+
+// flush emits one summary entry per path hit since the previous flush
+func (s *healthSummary) flush() {
+	for _, p := range s.paths {
+		stats := s.stats[p]
+
+		hits := stats.hits.Swap(0)
+		if hits == 0 {
+			continue
+		}
+		failures := stats.failures.Swap(0)
+		maxLatency := time.Duration(stats.maxLatency.Swap(0))
+
+		s.log.Info(
+			fmt.Sprintf("healthcheck summary: %d hits, %d failures, max %s", hits, failures, maxLatency),
+			zap.String("path", p),
+			zap.Int64("hits", hits),
+			zap.Int64("failures", failures),
+			zap.String("max_latency", maxLatency.String()),
+		)
+	}
+}
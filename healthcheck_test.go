@@ -0,0 +1,97 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerHealthCheckSummary(t *testing.T) {
+	defer func(f func(time.Duration) (<-chan time.Time, func())) { newTicker = f }(newTicker)
+
+	tick := make(chan time.Time)
+	newTicker = func(time.Duration) (<-chan time.Time, func()) {
+		return tick, func() {}
+	}
+
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	// summaries receives the summary entries once written, so the test waits for the ticker goroutine
+	summaries := make(chan struct{}, 1)
+	logger := zap.New(obs, zap.Hooks(func(e zapcore.Entry) error {
+		if strings.HasPrefix(e.Message, "healthcheck summary") {
+			summaries <- struct{}{}
+		}
+		return nil
+	}))
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{
+		HealthCheckPaths: []string{"/healthz"},
+	})
+
+	serve := func(path string, status int) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			return c.String(status, "")
+		}
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	serve("/healthz", http.StatusOK)
+	serve("/healthz", http.StatusOK)
+	serve("/healthz", http.StatusServiceUnavailable)
+	serve("/something", http.StatusOK)
+
+	assert.Equal(t, 2, logs.Len())
+	assert.Equal(t, "Server error", logs.AllUntimed()[0].Message)
+	assert.Equal(t, "Success", logs.AllUntimed()[1].Message)
+
+	tick <- time.Now()
+	<-summaries
+
+	assert.Equal(t, 3, logs.Len())
+
+	summary := logs.AllUntimed()[2]
+	assert.Contains(t, summary.Message, "healthcheck summary: 3 hits, 1 failures, max ")
+	assert.Equal(t, "/healthz", summary.ContextMap()["path"])
+	assert.Equal(t, int64(3), summary.ContextMap()["hits"])
+	assert.Equal(t, int64(1), summary.ContextMap()["failures"])
+
+	// Nothing is emitted for an interval without hits. The ticker goroutine only receives the second tick once the
+	// flush of the first one returned
+	tick <- time.Now()
+	tick <- time.Now()
+	assert.Equal(t, 3, logs.Len())
+
+	serve("/healthz", http.StatusOK)
+
+	assert.Nil(t, handle.Close())
+	assert.Nil(t, handle.Close())
+	<-summaries
+
+	assert.Equal(t, 4, logs.Len())
+	assert.Contains(t, logs.AllUntimed()[3].Message, "healthcheck summary: 1 hits, 0 failures")
+}
+
+func TestZapLoggerWithConfigRequiresHandle(t *testing.T) {
+	assert.PanicsWithError(t, "echozap: HealthCheckPaths and SummaryInterval require ZapLoggerWithHandle and closing the Handle on shutdown", func() {
+		ZapLoggerWithConfig(zap.NewNop(), ZapLoggerConfig{HealthCheckPaths: []string{"/healthz"}})
+	})
+	assert.Panics(t, func() {
+		ZapLoggerWithConfig(zap.NewNop(), ZapLoggerConfig{SummaryInterval: time.Minute})
+	})
+}
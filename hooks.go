@@ -0,0 +1,23 @@
+package echozap
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldsFuncFromContext adapts a hook reading only the echo context, such as ExpensiveFieldsFunc, to FieldsFunc
+func FieldsFuncFromContext(f func(c echo.Context) []zapcore.Field) func(c echo.Context, v Values) []zapcore.Field {
+	return func(c echo.Context, _ Values) []zapcore.Field {
+		return f(c)
+	}
+}
+
+// chainFieldsFuncs returns a FieldsFunc appending the fields of first then second, which may be nil
+func chainFieldsFuncs(first, second func(c echo.Context, v Values) []zapcore.Field) func(c echo.Context, v Values) []zapcore.Field {
+	if second == nil {
+		return first
+	}
+	return func(c echo.Context, v Values) []zapcore.Field {
+		return append(first(c, v), second(c, v)...)
+	}
+}
@@ -0,0 +1,89 @@
+package echozap
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerHooksValues(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/something?q=1", nil)
+	req.Header.Set(echo.HeaderXRequestID, "abc-123")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	// The error is turned into a 503 by the error handler, after the handler returned
+	h := func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "draining")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	var seen []Values
+	observe := func(v Values) Values {
+		v.Level, v.Message, v.Fields = 0, "", nil
+		seen = append(seen, v)
+		return v
+	}
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		ExpensiveFieldsFunc: func(c echo.Context) []zapcore.Field {
+			return []zapcore.Field{zap.String("legacy", c.Request().Method)}
+		},
+		LevelFunc: func(c echo.Context, v Values) zapcore.Level {
+			observe(v)
+			assert.Equal(t, zapcore.ErrorLevel, v.Level)
+			return zapcore.WarnLevel
+		},
+		MessageFunc: func(c echo.Context, v Values) string {
+			observe(v)
+			assert.Equal(t, zapcore.WarnLevel, v.Level)
+			return "Draining: " + v.URI
+		},
+		FieldsFunc: func(c echo.Context, v Values) []zapcore.Field {
+			observe(v)
+			assert.Equal(t, "Draining: /something?q=1", v.Message)
+			assert.Equal(t, v.Fields[0].Key, "error")
+			return []zapcore.Field{zap.Int("observed_status", v.Status)}
+		},
+		Emitters: []Emitter{EmitterFunc(func(c echo.Context, v Values) error {
+			observe(v)
+			return nil
+		})},
+	})(h)(c)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 4, len(seen))
+	for _, v := range seen[1:] {
+		assert.Equal(t, seen[0], v)
+	}
+
+	v := seen[0]
+	assert.Equal(t, http.StatusServiceUnavailable, v.Status)
+	assert.Equal(t, "abc-123", v.RequestID)
+	assert.Equal(t, http.MethodPost, v.Method)
+	assert.Equal(t, "/something", v.Path)
+	assert.Equal(t, "192.0.2.1", v.RemoteIP)
+	assert.True(t, errors.As(v.Err, new(*echo.HTTPError)))
+
+	assert.Equal(t, 1, logs.Len())
+	entry := logs.AllUntimed()[0]
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	assert.Equal(t, "Draining: /something?q=1", entry.Message)
+	fields := entry.ContextMap()
+	assert.Equal(t, int64(v.Status), fields["status"])
+	assert.Equal(t, v.Latency.String(), fields["latency"])
+	assert.Equal(t, int64(v.Status), fields["observed_status"])
+	assert.Equal(t, http.MethodPost, fields["legacy"])
+}
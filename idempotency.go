@@ -0,0 +1,36 @@
+package echozap
+
+import (
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxIdempotencyKeyLength is the maximum number of bytes of the idempotency key that are logged
+const maxIdempotencyKeyLength = 128
+
+// idempotencyKeyFields returns the fields describing an idempotency key header value.
+// Keys are expected to be printable and without whitespace, other values are flagged as invalid.
+func idempotencyKeyFields(value string) []zapcore.Field {
+	key := strings.TrimSpace(value)
+	if key == "" {
+		return nil
+	}
+
+	// The whole key is validated, before the logged part is cut
+	invalid := strings.IndexFunc(key, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) >= 0
+
+	key, truncated := truncate(key, maxIdempotencyKeyLength)
+
+	fields := []zapcore.Field{zap.String("idempotency_key", key)}
+	if truncated {
+		fields = append(fields, zap.Bool("idempotency_key_truncated", true))
+	}
+	if invalid {
+		fields = append(fields, zap.Bool("idempotency_key_invalid", true))
+	}
+
+	return fields
+}
@@ -0,0 +1,79 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		fields map[string]interface{}
+	}{
+		{
+			name:   "valid",
+			header: "  8e03978e-40d5-43e8-bc93-6894a57f9324 ",
+			fields: map[string]interface{}{"idempotency_key": "8e03978e-40d5-43e8-bc93-6894a57f9324"},
+		},
+		{
+			name:   "over-long",
+			header: strings.Repeat("k", 200),
+			fields: map[string]interface{}{"idempotency_key": strings.Repeat("k", 128), "idempotency_key_truncated": true},
+		},
+		{
+			name:   "invalid",
+			header: "key with\tspaces",
+			fields: map[string]interface{}{"idempotency_key": "key with\tspaces", "idempotency_key_invalid": true},
+		},
+		{
+			name:   "invalid past the cut",
+			header: strings.Repeat("k", 150) + " spoofed",
+			fields: map[string]interface{}{
+				"idempotency_key":           strings.Repeat("k", 128),
+				"idempotency_key_truncated": true,
+				"idempotency_key_invalid":   true,
+			},
+		},
+		{
+			name:   "missing",
+			fields: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+			if tt.header != "" {
+				req.Header.Set("Idempotency-Key", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{IdempotencyKeyHeader: "Idempotency-Key"})(h)(c)
+
+			assert.Nil(t, err)
+
+			logFields := logs.AllUntimed()[0].ContextMap()
+			for _, k := range []string{"idempotency_key", "idempotency_key_truncated", "idempotency_key_invalid"} {
+				assert.Equal(t, tt.fields[k], logFields[k], k)
+			}
+		})
+	}
+}
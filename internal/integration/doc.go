@@ -0,0 +1,6 @@
+// Package integration checks the access log entries of a realistic echo application against golden files.
+//
+// It is a separate module, so the dependencies of echo's middleware package are not required by echozap, tested by
+// make test and CI rather than go test ./... at the root of the repository.
+// Run go test . -update from its directory to regenerate the golden files after an intended change of the log contract.
+package integration
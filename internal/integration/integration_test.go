@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Unity-Technologies/echozap"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// scrubbed replaces the values changing between runs
+var scrubbed = []*regexp.Regexp{
+	regexp.MustCompile(`"(latency)":"[^"]*"`),
+	regexp.MustCompile(`"(stack)":"[^"]*"`),
+}
+
+type payload struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// newApp returns the application under test, logging to out with the built-in fields nested in groups
+func newApp(out *bytes.Buffer, groups map[string]string) *echo.Echo {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = ""
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(out), zap.DebugLevel))
+
+	var ids int
+	e := echo.New()
+	e.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		Generator: func() string {
+			ids++
+			return fmt.Sprintf("req-%03d", ids)
+		},
+	}))
+	e.Use(echozap.ZapLoggerWithConfig(logger, echozap.ZapLoggerConfig{
+		IncludeRequestLogMessage: true,
+		IncludeRedirectLocation:  true,
+		LogBindErrors:            true,
+		CapturePanics:            true,
+		FieldGroups:              groups,
+	}))
+	e.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{DisablePrintStack: true}))
+	e.Use(echozap.StashPanics(echozap.DefaultPanicContextKey))
+
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"id": c.Param("id")})
+	})
+	e.GET("/old", func(c echo.Context) error {
+		return c.Redirect(http.StatusMovedPermanently, "/new")
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		return errors.New("database unavailable")
+	})
+	e.GET("/forbidden", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusForbidden, "not yours")
+	})
+	e.GET("/panic", func(c echo.Context) error {
+		panic("nil map")
+	})
+	e.POST("/items", func(c echo.Context) error {
+		var p payload
+		if err := c.Bind(&p); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusCreated)
+	})
+
+	return e
+}
+
+type scenario struct {
+	name   string
+	method string
+	target string
+	body   string
+	status int
+}
+
+// serve runs the scenario and returns the scrubbed log output
+func serve(t *testing.T, tt scenario, groups map[string]string) []byte {
+	var out bytes.Buffer
+	e := newApp(&out, groups)
+
+	req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+	if tt.body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	req.Header.Set("User-Agent", "integration-test")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, tt.status, rec.Code)
+
+	got := out.Bytes()
+	for _, re := range scrubbed {
+		got = re.ReplaceAll(got, []byte(`"$1":"<$1>"`))
+	}
+	return got
+}
+
+// assertGolden compares got to the golden file, updated with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		assert.Nil(t, os.WriteFile(golden, got, 0o644))
+	}
+
+	want, err := os.ReadFile(golden)
+	assert.Nil(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestGolden(t *testing.T) {
+	tests := []scenario{
+		{name: "success", method: http.MethodGet, target: "/users/42?expand=true", status: http.StatusOK},
+		{name: "redirect", method: http.MethodGet, target: "/old", status: http.StatusMovedPermanently},
+		{name: "not_found", method: http.MethodGet, target: "/missing", status: http.StatusNotFound},
+		{name: "server_error", method: http.MethodGet, target: "/fail", status: http.StatusInternalServerError},
+		{name: "client_error", method: http.MethodGet, target: "/forbidden", status: http.StatusForbidden},
+		{name: "panic", method: http.MethodGet, target: "/panic", status: http.StatusInternalServerError},
+		{name: "bind_syntax_error", method: http.MethodPost, target: "/items", body: `{"name": "x",}`, status: http.StatusBadRequest},
+		{name: "bind_type_error", method: http.MethodPost, target: "/items", body: `{"count": "many"}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.name, serve(t, tt, nil))
+		})
+	}
+}
+
+func TestGoldenFieldGroups(t *testing.T) {
+	groups := map[string]string{
+		"remote_ip":  "net",
+		"host":       "net",
+		"user_agent": "net",
+		"status":     "http",
+		"latency":    "http",
+		"size":       "http",
+		"error":      "app",
+	}
+
+	tests := []scenario{
+		{name: "success", method: http.MethodGet, target: "/users/42?expand=true", status: http.StatusOK},
+		{name: "server_error", method: http.MethodGet, target: "/fail", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serve(t, tt, groups)
+			assertGolden(t, "grouped_"+tt.name, got)
+
+			// The layout does not depend on the map iteration order
+			for i := 0; i < 10; i++ {
+				assert.Equal(t, string(got), string(serve(t, tt, groups)))
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Unity-Technologies/echozap"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestCapturePanics checks the panics logged with echo's own Recover, registered before or after the middleware
+func TestCapturePanics(t *testing.T) {
+	recoverMiddleware := middleware.RecoverWithConfig(middleware.RecoverConfig{DisablePrintStack: true})
+
+	tests := []struct {
+		name        string
+		middlewares func(logger echo.MiddlewareFunc) []echo.MiddlewareFunc
+		panicked    bool
+	}{
+		{
+			name: "recover before",
+			middlewares: func(logger echo.MiddlewareFunc) []echo.MiddlewareFunc {
+				return []echo.MiddlewareFunc{recoverMiddleware, logger}
+			},
+			panicked: true,
+		},
+		{
+			name: "recover after with stash",
+			middlewares: func(logger echo.MiddlewareFunc) []echo.MiddlewareFunc {
+				return []echo.MiddlewareFunc{logger, recoverMiddleware, echozap.StashPanics(echozap.DefaultPanicContextKey)}
+			},
+			panicked: true,
+		},
+		{
+			// Recover handles the panic and returns nil, only the Server error is seen
+			name: "recover after without stash",
+			middlewares: func(logger echo.MiddlewareFunc) []echo.MiddlewareFunc {
+				return []echo.MiddlewareFunc{logger, recoverMiddleware}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			e := echo.New()
+			e.Use(tt.middlewares(echozap.ZapLoggerWithConfig(logger, echozap.ZapLoggerConfig{CapturePanics: true}))...)
+
+			e.GET("/something", func(c echo.Context) error {
+				panic("boom")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+			assert.Equal(t, 1, logs.Len())
+			entry := logs.AllUntimed()[0]
+			assert.Equal(t, "Server error", entry.Message)
+			fields := entry.ContextMap()
+			assert.Equal(t, int64(http.StatusInternalServerError), fields["status"])
+			if !tt.panicked {
+				assert.NotContains(t, fields, "panicked")
+				return
+			}
+			assert.Equal(t, true, fields["panicked"])
+			assert.Contains(t, fields["panic"], "boom")
+			assert.Contains(t, fields["stack"], "panics_test.go")
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package echozap
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IPAnonMode defines how the client IPs are anonymized in the entries
+type IPAnonMode uint8
+
+const (
+	// IPAnonNone logs the IPs as they are
+	IPAnonNone IPAnonMode = iota
+	// IPAnonTruncate zeroes the last octet of IPv4 addresses and the last 80 bits of IPv6 addresses
+	IPAnonTruncate
+	// IPAnonHash replaces the IPs by a keyed hash, so the requests of a client can still be grouped
+	IPAnonHash
+)
+
+// ipHashLength is the number of hex characters of the hashed IPs
+const ipHashLength = 16
+
+// forwardingHeaders are the request headers holding client IPs, anonymized in request_headers
+var forwardingHeaders = []string{echo.HeaderXForwardedFor, echo.HeaderXRealIP, "Forwarded"}
+
+// ipAnonymizer anonymizes the IPs with pooled HMACs
+type ipAnonymizer struct {
+	pool sync.Pool
+}
+
+// newIPAnonymizer returns an anonymizer hashing with secret, or with a random key when it is empty
+func newIPAnonymizer(secret []byte) *ipAnonymizer {
+	key := append([]byte(nil), secret...)
+	if len(key) == 0 {
+		key = make([]byte, sha256.Size)
+		if _, err := rand.Read(key); err != nil {
+			panic(err)
+		}
+	}
+
+	a := &ipAnonymizer{}
+	a.pool.New = func() interface{} {
+		return hmac.New(sha256.New, key)
+	}
+	return a
+}
+
+// ip returns the anonymized IP, which may have a port. Each IP of a comma separated list is anonymized and the values
+// that are not IPs are redacted, so malformed values cannot leak the client IPs.
+func (a *ipAnonymizer) ip(mode IPAnonMode, s string) string {
+	if mode == IPAnonNone {
+		return s
+	}
+
+	parts := strings.Split(s, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			parts[i] = part
+			continue
+		}
+		anonymized, ok := a.address(mode, part)
+		if !ok {
+			anonymized = redactedValue
+		}
+		parts[i] = anonymized
+	}
+	return strings.Join(parts, ",")
+}
+
+// address returns the anonymized address, which may have a port, and whether s is one
+func (a *ipAnonymizer) address(mode IPAnonMode, s string) (string, bool) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		host, port = s, ""
+	}
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err != nil {
+		return "", false
+	}
+	addr = addr.Unmap()
+
+	var anonymized string
+	if mode == IPAnonHash {
+		anonymized = a.hash(addr)
+	} else {
+		bits := 48
+		if addr.Is4() {
+			bits = 24
+		}
+		p, _ := addr.Prefix(bits)
+		anonymized = p.Addr().String()
+	}
+
+	if port != "" {
+		return net.JoinHostPort(anonymized, port), true
+	}
+	return anonymized, true
+}
+
+func (a *ipAnonymizer) hash(addr netip.Addr) string {
+	h := a.pool.Get().(hash.Hash)
+	defer a.pool.Put(h)
+	h.Reset()
+
+	b, _ := addr.MarshalBinary()
+	h.Write(b)
+
+	var buf [sha256.Size]byte
+	sum := h.Sum(buf[:0])
+	return hex.EncodeToString(sum[:ipHashLength/2])
+}
+
+// chain anonymizes the IPs of a comma separated list, like X-Forwarded-For, or of the for= and by= parameters
+// of a Forwarded header
+func (a *ipAnonymizer) chain(mode IPAnonMode, s string) string {
+	if mode == IPAnonNone {
+		return s
+	}
+
+	elements := strings.Split(s, ",")
+	for i, element := range elements {
+		pairs := strings.Split(element, ";")
+		for j, pair := range pairs {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				pairs[j] = a.tokens(mode, pair)
+				continue
+			}
+			if n := strings.ToLower(strings.TrimSpace(name)); n == "for" || n == "by" {
+				quoted := strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) && len(value) > 1
+				if quoted {
+					value = value[1 : len(value)-1]
+				}
+				value = a.ip(mode, value)
+				if quoted {
+					value = `"` + value + `"`
+				}
+				pairs[j] = name + "=" + value
+			}
+		}
+		elements[i] = strings.Join(pairs, ";")
+	}
+	return strings.Join(elements, ",")
+}
+
+// tokens anonymizes the IPs among the space separated tokens of s and redacts the other tokens, so malformed values
+// do not leak them
+func (a *ipAnonymizer) tokens(mode IPAnonMode, s string) string {
+	tokens := strings.Split(s, " ")
+	for i, token := range tokens {
+		tokens[i] = a.ip(mode, token)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// header returns h with the forwarding headers anonymized, copied when they are present
+func (a *ipAnonymizer) header(mode IPAnonMode, h http.Header) http.Header {
+	if mode == IPAnonNone {
+		return h
+	}
+
+	var anonymized http.Header
+	for _, k := range forwardingHeaders {
+		values, ok := h[k]
+		if !ok {
+			continue
+		}
+		if anonymized == nil {
+			anonymized = h.Clone()
+		}
+		for i, v := range values {
+			anonymized[k][i] = a.chain(mode, v)
+		}
+	}
+	if anonymized == nil {
+		return h
+	}
+	return anonymized
+}
@@ -0,0 +1,203 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestIPAnonymizerIP(t *testing.T) {
+	a := newIPAnonymizer([]byte("secret"))
+
+	tests := []struct {
+		ip       string
+		truncate string
+	}{
+		{ip: "192.0.2.123", truncate: "192.0.2.0"},
+		{ip: "::ffff:192.0.2.123", truncate: "192.0.2.0"},
+		{ip: "2001:db8:85a3:8d3:1319:8a2e:370:7348", truncate: "2001:db8:85a3::"},
+		{ip: "192.0.2.123:8080", truncate: "192.0.2.0:8080"},
+		{ip: "[2001:db8::1]:4711", truncate: "[2001:db8::]:4711"},
+		{ip: "203.0.113.7,198.51.100.1", truncate: "203.0.113.0,198.51.100.0"},
+		{ip: "  203.0.113.7 ", truncate: "203.0.113.0"},
+		{ip: "unknown", truncate: redactedValue},
+		{ip: "203.0.113.7, unknown", truncate: "203.0.113.0," + redactedValue},
+		{ip: "203.0.113.7 spoofed", truncate: redactedValue},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.ip, a.ip(IPAnonNone, tt.ip))
+		assert.Equal(t, tt.truncate, a.ip(IPAnonTruncate, tt.ip), tt.ip)
+	}
+
+	hashed := a.ip(IPAnonHash, "192.0.2.123")
+	assert.Len(t, hashed, ipHashLength)
+	assert.Equal(t, hashed, a.ip(IPAnonHash, "::ffff:192.0.2.123"))
+	assert.NotEqual(t, hashed, a.ip(IPAnonHash, "192.0.2.124"))
+	assert.NotEqual(t, hashed, newIPAnonymizer([]byte("other")).ip(IPAnonHash, "192.0.2.123"))
+
+	hashed6 := a.ip(IPAnonHash, "2001:db8::1")
+	assert.Len(t, hashed6, ipHashLength)
+	assert.NotEqual(t, hashed6, a.ip(IPAnonHash, "2001:db8::2"))
+}
+
+func TestIPAnonymizerChain(t *testing.T) {
+	a := newIPAnonymizer([]byte("secret"))
+
+	assert.Equal(t, "203.0.113.0, 2001:db8::, [REDACTED]", a.chain(IPAnonTruncate, "203.0.113.7, 2001:db8::7, unknown"))
+	assert.Equal(t, `for=192.0.2.0;proto=https, for="[2001:db8::]:4711";by=203.0.113.0`,
+		a.chain(IPAnonTruncate, `for=192.0.2.60;proto=https, for="[2001:db8:0:0:cafe::17]:4711";by=203.0.113.43`))
+	assert.Equal(t, a.ip(IPAnonHash, "203.0.113.7")+","+a.ip(IPAnonHash, "198.51.100.1"), a.chain(IPAnonHash, "203.0.113.7,198.51.100.1"))
+}
+
+func TestZapLoggerIPAnonymization(t *testing.T) {
+	secret := []byte("secret")
+	a := newIPAnonymizer(secret)
+
+	tests := []struct {
+		name      string
+		mode      IPAnonMode
+		country   string
+		forwarded string
+		remoteIP  string
+		headers   string
+	}{
+		{
+			name:      "none",
+			forwarded: "192.0.2.123, 198.51.100.7",
+			remoteIP:  "192.0.2.123",
+			headers:   "192.0.2.123, 198.51.100.7",
+		},
+		{
+			name:      "truncate IPv4",
+			mode:      IPAnonTruncate,
+			forwarded: "192.0.2.123, 198.51.100.7",
+			remoteIP:  "192.0.2.0",
+			headers:   "192.0.2.0, 198.51.100.0",
+		},
+		{
+			name:      "truncate IPv6",
+			mode:      IPAnonTruncate,
+			forwarded: "2001:db8:85a3:8d3::7348",
+			remoteIP:  "2001:db8:85a3::",
+			headers:   "2001:db8:85a3::",
+		},
+		{
+			name:      "hash IPv4",
+			mode:      IPAnonHash,
+			forwarded: "192.0.2.123",
+			remoteIP:  a.ip(IPAnonHash, "192.0.2.123"),
+			headers:   a.ip(IPAnonHash, "192.0.2.123"),
+		},
+		{
+			name:      "hash IPv6",
+			mode:      IPAnonHash,
+			forwarded: "2001:db8::1",
+			remoteIP:  a.ip(IPAnonHash, "2001:db8::1"),
+			headers:   a.ip(IPAnonHash, "2001:db8::1"),
+		},
+		{
+			name:      "truncate list without spaces",
+			mode:      IPAnonTruncate,
+			forwarded: "203.0.113.7,198.51.100.1",
+			remoteIP:  "203.0.113.0,198.51.100.0",
+			headers:   "203.0.113.0,198.51.100.0",
+		},
+		{
+			name:      "truncate surrounding whitespace",
+			mode:      IPAnonTruncate,
+			forwarded: "  203.0.113.7",
+			remoteIP:  "203.0.113.0",
+			headers:   "  203.0.113.0",
+		},
+		{
+			name:      "redact junk",
+			mode:      IPAnonHash,
+			forwarded: "unknown",
+			remoteIP:  redactedValue,
+			headers:   redactedValue,
+		},
+		{
+			name:      "per request override",
+			mode:      IPAnonNone,
+			country:   "DE",
+			forwarded: "192.0.2.123",
+			remoteIP:  "192.0.2.0",
+			headers:   "192.0.2.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			req.Header.Set(echo.HeaderXForwardedFor, tt.forwarded)
+			if tt.country != "" {
+				req.Header.Set("CF-IPCountry", tt.country)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			mode := tt.mode
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+				VerboseSampleRate: 1,
+				VerboseFields:     VerboseFieldsConfig{Headers: true},
+				IPAnonymization:   mode,
+				IPAnonSecret:      secret,
+				IPAnonFunc: func(c echo.Context) IPAnonMode {
+					if c.Request().Header.Get("CF-IPCountry") == "DE" {
+						return IPAnonTruncate
+					}
+					return mode
+				},
+			})(h)(c)
+
+			assert.Nil(t, err)
+
+			logFields := logs.AllUntimed()[0].ContextMap()
+			assert.Equal(t, tt.remoteIP, logFields["remote_ip"])
+			assert.Equal(t, tt.headers, logFields["request_headers"].(map[string]interface{})[echo.HeaderXForwardedFor])
+
+			// The request is left untouched
+			assert.Equal(t, tt.forwarded, req.Header.Get(echo.HeaderXForwardedFor))
+		})
+	}
+}
+
+func TestZapLoggerIPAnonymizationRealIPRaw(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	req.Header.Set(echo.HeaderXForwardedFor, "192.0.2.123 spoofed")
+	req.RemoteAddr = "198.51.100.7:1234"
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{ValidateRealIP: true, IPAnonymization: IPAnonTruncate})(h)(c)
+
+	assert.Nil(t, err)
+
+	logFields := logs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, "198.51.100.0", logFields["remote_ip"])
+	assert.Equal(t, "192.0.2.0 [REDACTED]", logFields["real_ip_raw"])
+}
@@ -0,0 +1,21 @@
+package echozap
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// checkLatency guards against clock jumps. The latency is measured on the monotonic clock when available, but a clock
+// without monotonic reading (e.g. a replaced clock) can go backwards: negative latencies are clamped to 0 and flagged,
+// latencies above max, when set, are only flagged.
+func checkLatency(d, max time.Duration) (time.Duration, []zapcore.Field) {
+	switch {
+	case d < 0:
+		return 0, []zapcore.Field{zap.Bool("latency_clamped", true)}
+	case max > 0 && d > max:
+		return d, []zapcore.Field{zap.Bool("latency_suspect", true)}
+	}
+	return d, nil
+}
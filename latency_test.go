@@ -0,0 +1,69 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerLatencyClockJumps(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    time.Duration
+		latency string
+		flag    string
+	}{
+		{name: "backwards", step: -time.Hour, latency: "0s", flag: "latency_clamped"},
+		{name: "absurd", step: 3 * time.Hour, latency: "3h0m0s", flag: "latency_suspect"},
+		{name: "plausible", step: 20 * time.Millisecond, latency: "20ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{MaxPlausibleLatency: time.Minute})
+
+			// A wall clock without monotonic reading, stepped by the handler
+			clock := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+			handle.now = func() time.Time { return clock }
+
+			h := func(c echo.Context) error {
+				clock = clock.Add(tt.step)
+				return c.String(http.StatusOK, "")
+			}
+
+			assert.Nil(t, mw(h)(c))
+
+			assert.Equal(t, 1, logs.Len())
+			fields := logs.AllUntimed()[0].ContextMap()
+			assert.Equal(t, tt.latency, fields["latency"])
+			for _, flag := range []string{"latency_clamped", "latency_suspect"} {
+				if flag == tt.flag {
+					assert.Equal(t, true, fields[flag])
+				} else {
+					assert.NotContains(t, fields, flag)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckLatencyDisabledMax(t *testing.T) {
+	d, fields := checkLatency(48*time.Hour, 0)
+	assert.Equal(t, 48*time.Hour, d)
+	assert.Nil(t, fields)
+}
@@ -0,0 +1,64 @@
+package echozap
+
+import (
+	"net/http"
+	"net/netip"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// trustedNetworks matches the peers allowed to override the level of their entry
+type trustedNetworks []netip.Prefix
+
+func newTrustedNetworks(prefixes []netip.Prefix) trustedNetworks {
+	t := make(trustedNetworks, 0, len(prefixes))
+	for _, p := range prefixes {
+		if p.IsValid() {
+			t = append(t, p.Masked())
+		}
+	}
+	return t
+}
+
+// contains reports whether the address of remoteAddr, with or without a port, is in a trusted network
+func (t trustedNetworks) contains(remoteAddr string) bool {
+	if len(t) == 0 {
+		return false
+	}
+
+	var addr netip.Addr
+	if ap, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		addr = ap.Addr()
+	} else if addr, err = netip.ParseAddr(remoteAddr); err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, p := range t {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// levelOverride is the minimum level requested for the entry of a request
+type levelOverride struct {
+	level   zapcore.Level
+	ok      bool
+	invalid bool
+}
+
+// parseLevelOverride reads the level from the header of a request coming from a trusted peer
+func parseLevelOverride(req *http.Request, header string, trusted trustedNetworks) levelOverride {
+	value := req.Header.Get(header)
+	if value == "" || !trusted.contains(req.RemoteAddr) {
+		return levelOverride{}
+	}
+
+	level, err := zapcore.ParseLevel(value)
+	if err != nil {
+		return levelOverride{invalid: true}
+	}
+	return levelOverride{level: level, ok: true}
+}
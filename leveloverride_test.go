@@ -0,0 +1,65 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerLevelOverride(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		value      string
+		level      zapcore.Level
+		verbose    bool
+		invalid    bool
+	}{
+		{name: "trusted debug", remoteAddr: "10.1.2.3:4321", value: "debug", level: zapcore.InfoLevel, verbose: true},
+		{name: "trusted warn", remoteAddr: "10.1.2.3:4321", value: "WARN", level: zapcore.WarnLevel, verbose: true},
+		{name: "trusted mapped", remoteAddr: "[::ffff:10.1.2.3]:4321", value: "error", level: zapcore.ErrorLevel, verbose: true},
+		{name: "untrusted", remoteAddr: "192.0.2.1:4321", value: "error", level: zapcore.InfoLevel},
+		{name: "untrusted invalid", remoteAddr: "192.0.2.1:4321", value: "chatty", level: zapcore.InfoLevel},
+		{name: "trusted invalid", remoteAddr: "10.1.2.3:4321", value: "chatty", level: zapcore.InfoLevel, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Log-Level", tt.value)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+				LevelOverrideHeader: "X-Log-Level",
+				TrustedProxies:      []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")},
+			})(h)(c)
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, 1, logs.Len())
+			entry := logs.AllUntimed()[0]
+			assert.Equal(t, tt.level, entry.Level)
+			fields := entry.ContextMap()
+			assert.Equal(t, tt.verbose, fields["verbose_sample"] != nil)
+			assert.Equal(t, tt.invalid, fields["level_override_invalid"] != nil)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package echozap
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxLocaleRawLength is the maximum number of bytes of an unparsable Accept-Language header that are logged
+const maxLocaleRawLength = 64
+
+// localeFields returns the preferred language tag of the Accept-Language header as locale. Headers that cannot be parsed
+// are logged as locale_raw, and the field is omitted when the wildcard is preferred.
+func localeFields(header http.Header) []zapcore.Field {
+	value := strings.Join(header.Values("Accept-Language"), ",")
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	tag, ok := preferredLanguage(value)
+	if !ok {
+		raw, _ := truncate(value, maxLocaleRawLength)
+		return []zapcore.Field{zap.String("locale_raw", raw)}
+	}
+	if tag == "" || tag == "*" {
+		return nil
+	}
+	return []zapcore.Field{zap.String("locale", tag)}
+}
+
+// preferredLanguage returns the tag of the Accept-Language value with the highest quality, the first one on ties.
+// It reports false when an element of the value is malformed, and returns an empty tag when all have a zero quality.
+func preferredLanguage(value string) (string, bool) {
+	best, bestQ := "", 0.0
+	for _, element := range strings.Split(value, ",") {
+		element = strings.TrimSpace(element)
+		if element == "" {
+			continue
+		}
+
+		tag, params, _ := strings.Cut(element, ";")
+		tag = strings.TrimSpace(tag)
+		if tag != "*" && !validLanguageTag(tag) {
+			return "", false
+		}
+
+		q := 1.0
+		if params = strings.TrimSpace(params); params != "" {
+			name, v, ok := strings.Cut(params, "=")
+			if !ok || strings.TrimSpace(name) != "q" {
+				return "", false
+			}
+			var err error
+			if q, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil || q < 0 || q > 1 {
+				return "", false
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return canonicalLanguageTag(best), true
+}
+
+// validLanguageTag reports whether tag is made of subtags of 1 to 8 letters and digits, the first one being letters
+func validLanguageTag(tag string) bool {
+	for i, sub := range strings.Split(tag, "-") {
+		if len(sub) == 0 || len(sub) > 8 {
+			return false
+		}
+		for _, r := range sub {
+			isLetter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+			if !isLetter && (i == 0 || r < '0' || r > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// canonicalLanguageTag returns the tag with the BCP 47 case conventions: language in lower case, script in title case
+// and region in upper case (e.g. zh-Hant-TW)
+func canonicalLanguageTag(tag string) string {
+	subs := strings.Split(strings.ToLower(tag), "-")
+	for i := 1; i < len(subs); i++ {
+		switch len(subs[i]) {
+		case 2:
+			subs[i] = strings.ToUpper(subs[i])
+		case 4:
+			subs[i] = strings.ToUpper(subs[i][:1]) + subs[i][1:]
+		}
+	}
+	return strings.Join(subs, "-")
+}
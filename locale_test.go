@@ -0,0 +1,105 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerLocale(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		fields map[string]interface{}
+	}{
+		{
+			name:   "single",
+			values: []string{"pt-br"},
+			fields: map[string]interface{}{"locale": "pt-BR"},
+		},
+		{
+			name:   "q-values",
+			values: []string{"fr;q=0.7, de-CH;q=0.9, en;q=0.8"},
+			fields: map[string]interface{}{"locale": "de-CH"},
+		},
+		{
+			name:   "tie keeps the first",
+			values: []string{"es-419, zh-hant-tw;q=1.0"},
+			fields: map[string]interface{}{"locale": "es-419"},
+		},
+		{
+			name:   "multiple headers",
+			values: []string{"en;q=0.5", "ja;q=0.6"},
+			fields: map[string]interface{}{"locale": "ja"},
+		},
+		{
+			name:   "zero quality",
+			values: []string{"en;q=0"},
+			fields: map[string]interface{}{},
+		},
+		{
+			name:   "wildcard",
+			values: []string{"*, en;q=0.5"},
+			fields: map[string]interface{}{},
+		},
+		{
+			name:   "wildcard with lower quality",
+			values: []string{"*;q=0.1, it"},
+			fields: map[string]interface{}{"locale": "it"},
+		},
+		{
+			name:   "malformed tag",
+			values: []string{"en_US, fr"},
+			fields: map[string]interface{}{"locale_raw": "en_US, fr"},
+		},
+		{
+			name:   "malformed quality",
+			values: []string{"en;q=high"},
+			fields: map[string]interface{}{"locale_raw": "en;q=high"},
+		},
+		{
+			name:   "malformed over-long",
+			values: []string{strings.Repeat("x", 100)},
+			fields: map[string]interface{}{"locale_raw": strings.Repeat("x", 64)},
+		},
+		{
+			name:   "missing",
+			fields: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			for _, v := range tt.values {
+				req.Header.Add("Accept-Language", v)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{IncludeLocale: true})(h)(c)
+
+			assert.Nil(t, err)
+
+			logFields := logs.AllUntimed()[0].ContextMap()
+			for _, k := range []string{"locale", "locale_raw"} {
+				assert.Equal(t, tt.fields[k], logFields[k], k)
+			}
+		})
+	}
+}
@@ -1,8 +1,15 @@
 package echozap
 
 import (
-	"fmt"
+	"errors"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -19,17 +26,236 @@ type (
 		// Whether to include the request method and URI in the log message field
 		// Makes it easier to visualize the logs in systems that expand only the log message by default(e.g. Stackdriver)
 		IncludeRequestLogMessage bool
+		// Fraction of requests (0 to 1) logged with the verbose field set described by VerboseFields.
+		// When the request has an ID the decision is derived from its hash, so retries of the same request are consistently verbose or not
+		VerboseSampleRate float64
+		// VerboseFields defines which extra fields are included in verbose entries.
+		// These fields are considered expensive and only computed when the entry is enabled by the logger core
+		VerboseFields VerboseFieldsConfig
+		// Whether to track errors returned while writing the response (e.g. the client went away)
+		// and log them as write_error along with bytes_written and bytes_intended
+		LogWriteErrors bool
+		// Whether to log successful requests and redirections at Warn level when a write error occurred.
+		// Requires LogWriteErrors
+		WarnOnWriteError bool
+		// ExpensiveFieldsFunc returns additional fields that are costly to compute (header dumps, form parsing, route lookup...).
+		// Like the verbose field set, it only runs when the entry is enabled by the logger core.
+		// Prefer FieldsFunc, which receives the values of the request
+		ExpensiveFieldsFunc func(c echo.Context) []zapcore.Field
+		// FieldsFunc is like ExpensiveFieldsFunc but receives the values of the request, with Fields holding the fields so far.
+		// It runs after ExpensiveFieldsFunc when both are set
+		FieldsFunc func(c echo.Context, v Values) []zapcore.Field
+		// LevelFunc returns the level of the entry. v.Level holds the level derived from the status
+		LevelFunc func(c echo.Context, v Values) zapcore.Level
+		// MessageFunc returns the message of the entry. v.Message holds the default message, including the request part
+		// when IncludeRequestLogMessage is set
+		MessageFunc func(c echo.Context, v Values) string
+		// AccessCore, when set, is used exclusively to emit the access log entries instead of the logger passed to the middleware.
+		// This keeps the access log verbosity independent from the application log verbosity
+		AccessCore zapcore.Core
+		// Paths whose successful requests are summarized instead of logged individually.
+		// Requests failing with a non-2xx status are still logged immediately.
+		// The summaries run in the background, so the middleware must be built with ZapLoggerWithHandle and the Handle closed on shutdown
+		HealthCheckPaths []string
+		// Interval between health check summaries. Defaults to 60s
+		HealthCheckSummaryInterval time.Duration
+		// SampleStats returned by WrapWithSampling, reported by Handle.Stats
+		SampleStats *SampleStats
+		// Whether to split the latency in latency_pre_handler, latency_handler and latency_write.
+		// The pre-handler phase is only measured when MarkHandler is registered right before the handler
+		IncludeLatencyBreakdown bool
+		// Whether to log the response Location header as location on redirects (301, 302, 303, 307 and 308)
+		IncludeRedirectLocation bool
+		// Request header holding the idempotency key logged as idempotency_key (e.g. Idempotency-Key). Empty disables it
+		IdempotencyKeyHeader string
+		// Whether to log the details of c.Bind and c.Validate failures: validation_errors for JSON type errors and
+		// validator errors (anything exposing Field() and Tag() per failed field), bind_error_offset for JSON syntax errors
+		LogBindErrors bool
+		// Names of the built-in fields to emit, all of them when empty.
+		// Valid names are remote_ip, latency, host, request, status, size, user_agent, request_id and error
+		OnlyFields []string
+		// Names of the built-in fields not to emit
+		ExcludeFields []string
+		// Whether to set the X-Request-ID and X-Response-Time response headers when the headers are written.
+		// X-Response-Time is measured from the same start as the logged latency, but up to the header write (TTFB)
+		// since trailers are not universally supported
+		StampResponseHeaders bool
+		// Development enables checks meant to catch hook bugs, at some performance cost.
+		// The context given to the hooks panics when used after the request completed, since echo reuses it
+		Development bool
+		// ContextFields maps echo context keys (see echo.Context.Set) to the log fields they are logged as.
+		// Strings, numbers and booleans are logged, missing keys and nil values are skipped
+		ContextFields map[string]string
+		// Whether to also log context values of other types (structs, slices...) listed in ContextFields
+		ContextFieldsStringify bool
+		// Whether to check that c.RealIP() is a valid IP address. When it is not (e.g. a forged X-Forwarded-For),
+		// remote_ip is the address of the direct peer and the invalid value is logged as real_ip_raw
+		ValidateRealIP bool
+		// Whether to log an error_fingerprint grouping similar errors, and the error_type, for server errors
+		ErrorFingerprint bool
+		// ErrorRate defines the adaptive mode: while the server error rate is above the threshold,
+		// all entries carry an error_rate field and optionally the verbose field set
+		ErrorRate ErrorRateConfig
+		// LogicalStatusFunc returns the logical status carried by a response, for endpoints always returning HTTP 200
+		// (e.g. a grpc-status header). When it returns true, the status is logged as logical_status
+		LogicalStatusFunc func(c echo.Context) (code int, ok bool)
+		// Whether the logical status can raise the level of the entry, so a 200 with grpc-status 13 logs as a Server error.
+		// Codes below 100 are interpreted as gRPC codes, others as HTTP statuses
+		LevelFromLogicalStatus bool
+		// Emitters receive the values of each entry after it was written to the zap logger, in order.
+		// To emit only through the emitters, pass zap.NewNop() as the logger
+		Emitters []Emitter
+		// Interval of the summary entries reporting the count, latency percentiles and server errors of the requests.
+		// Zero disables the summaries. The summaries run in the background, so the middleware must be built with
+		// ZapLoggerWithHandle and the Handle closed on shutdown, which also flushes the last one
+		SummaryInterval time.Duration
+		// WatchLogger receives a verbose copy of the entries of the requests matching the rules of Handle.Watchlist,
+		// in addition to the normal logging
+		WatchLogger *zap.Logger
+		// Whether to log the request range, and the outcome of range and conditional requests:
+		// partial=true for 206 responses, not_modified=true and the etag for 304 responses
+		IncludeConditionalRequestInfo bool
+		// RequestLogMessageFunc formats the request part appended to the message when IncludeRequestLogMessage is set.
+		// The URI it receives is stripped of control characters and capped, since messages are often displayed unescaped.
+		// Defaults to DefaultRequestLogMessage
+		RequestLogMessageFunc func(method, uri string) string
+		// Whether the message uses the route template (e.g. /users/:id) instead of the raw URI, to bound its cardinality
+		MessageUsesRoute bool
+		// Whether to append the method and the route template to the message (e.g. "Success GET /users/:id"), so the messages,
+		// and the sampling keys of zap which are derived from them, are per route. Requests matching no route share "<unmatched>".
+		// It takes precedence over IncludeRequestLogMessage, and MessageFunc receives the resulting message
+		MessageIncludesRoute bool
+		// TypeEncoders build the fields of user defined types (e.g. money amounts), for the values of ContextFields, Set and
+		// the fields of FieldsFunc created with zap.Any. Values of other types are logged like zap.Any does
+		TypeEncoders map[reflect.Type]func(key string, v interface{}) zapcore.Field
+		// PathPolicies override the sampling of the requests they match, once routed. The first matching policy wins,
+		// requests matching none are logged as usual. Summaries and the error rate still account for all the requests
+		PathPolicies []PathPolicy
+		// Whether to log the media type of the response as response_content_type, and its format as json, xml, html, text,
+		// proto or other. Both are omitted when the response has no Content-Type
+		IncludeResponseContentType bool
+		// Whether to omit the caller from the entries, which otherwise points into the middleware when the logger has zap.AddCaller
+		DisableCaller bool
+		// Options applied once to the logger of the access log entries, e.g. zap.Hooks scoped to them
+		// or zap.AddCallerSkip(1) to report the caller of the middleware. They are applied after DisableCaller
+		Options []zap.Option
+		// Latency above which entries are flagged with latency_suspect=true, their latency is kept. Zero disables it.
+		// Negative latencies, from clock jumps, are always logged as 0 with latency_clamped=true
+		MaxPlausibleLatency time.Duration
+		// EmitFunc, when set, writes the entries instead of the middleware, which still computes their level, message and fields.
+		// It receives the logger the entries would be written to. The WatchLogger and the Emitters are unaffected.
+		// Since the middleware cannot tell whether the entry will be written, the expensive fields are always computed
+		EmitFunc func(log *zap.Logger, level zapcore.Level, msg string, fields []zapcore.Field)
+		// ClientFingerprint defines the client_fingerprint field, derived from the client network and User-Agent. Nil disables it
+		ClientFingerprint *FingerprintConfig
+		// Duration of the write phase, from the header write to the end of the last body write, above which entries are flagged
+		// with slow_write=true and the write_latency. It tells slow clients from slow handlers. Zero disables it
+		WriteLatencyBudget time.Duration
+		// Whether to log successful requests and redirections at Warn level when the write phase exceeded WriteLatencyBudget
+		WarnOnSlowWrite bool
+		// APIVersionExtractors find the API version of the request, logged as api_version (e.g. v2). The first one finding it wins.
+		// See VersionFromPathPrefix, VersionFromHeader and VersionFromAcceptVendor
+		APIVersionExtractors []func(c echo.Context) (string, bool)
+		// Whether to explain the decisions of the middleware, for staging environments: entries carry a log_decision field
+		// describing their level and sampling, suppressed entries are reported by a Debug "entry suppressed" record with the reason
+		ExplainDecisions bool
+		// Approximate maximum size in bytes of the encoded entries. Larger entries lose the optional fields, in order:
+		// request_body, request_headers, request_trailers, trailers, stack and user_agent, and are flagged
+		// with entry_trimmed=true and the trimmed_fields. Other fields are kept. Zero disables it
+		MaxEntryBytes int
+		// Whether to log the attempt count of the request, read from the first of RetryAttemptHeaders holding a positive
+		// integer, as attempt. Retries are flagged with is_retry=true and invalid values are logged as attempt_raw
+		IncludeRetryMetadata bool
+		// Request headers holding the attempt count, in order of preference. Defaults to DefaultRetryAttemptHeaders
+		RetryAttemptHeaders []string
+		// Sink, when set, writes the entries it is enabled for instead of the logger and EmitFunc, e.g. to log/slog with
+		// the slogemit package. The logger still receives the summaries and the reports of the middleware
+		Sink Sink
+		// Whether to log the preferred language of the Accept-Language header as locale (e.g. pt-BR). Unparsable headers
+		// are logged as locale_raw, and the field is omitted when the wildcard is preferred
+		IncludeLocale bool
+		// How the client IPs are anonymized in remote_ip, real_ip_raw and the forwarding headers of request_headers.
+		// The values that are not IPs are redacted
+		IPAnonymization IPAnonMode
+		// IPAnonFunc, when set, decides the anonymization of the request instead of IPAnonymization (e.g. from a geo header)
+		IPAnonFunc func(c echo.Context) IPAnonMode
+		// Secret key of the HMAC of IPAnonHash. Defaults to a random key, so the hashes only match within the process
+		IPAnonSecret []byte
+		// FieldGroups nests built-in fields in objects, by field name (e.g. "remote_ip": "net", "status": "http").
+		// The groups follow the ungrouped fields, sorted by name. Objects are used rather than zap.Namespace,
+		// as namespaces cannot be closed to start a sibling one
+		FieldGroups map[string]string
+		// Experimental: whether to omit the CompactFields repeating the values of a base entry, to reduce the size of the output.
+		// Base entries are written every CompactResyncEvery entries with repeat_base, a sequence number, and repeat_fields,
+		// the other entries reference theirs with repeat_of. The output requires a decoding step, see RepeatDecoder, and
+		// must not mix the entries of several middlewares. The entries are written one at a time, so the bases precede
+		// the entries referencing them. The WatchLogger and the Emitters receive the full entries
+		CompactRepeats bool
+		// Fields compacted by CompactRepeats, matched after FieldGroups so groups can be compacted as a whole.
+		// Defaults to DefaultCompactFields
+		CompactFields []string
+		// Number of entries between two base entries of CompactRepeats. Defaults to DefaultCompactResyncEvery
+		CompactResyncEvery int
+		// Response trailers to log under trailers, read once the response is written. Missing trailers are omitted
+		LogResponseTrailers []string
+		// Request trailers to log under request_trailers. They are only available when the handler read the whole body
+		LogRequestTrailers []string
+		// Priority defines how the priority of the request is derived from a header or the context
+		Priority PriorityConfig
+		// User-Agent patterns of synthetic and monitoring traffic, matched case-insensitively anywhere in the User-Agent.
+		// See DefaultSyntheticAgents
+		SyntheticUserAgents []string
+		// Whether to add synthetic=true to the entries of synthetic requests
+		TagSynthetic bool
+		// Whether to suppress the entries of synthetic requests
+		SkipSynthetic bool
+		// Whether to log the effective status instead of 0 or an interim 1xx status (e.g. 103 Early Hints):
+		// the final status written, 200 when the handler wrote a body or nothing at all, or the status of the error.
+		// The status reported by echo is logged as status_raw when it differs
+		NormalizeStatus bool
+		// Request header carrying a minimum level for the entry of the request (e.g. X-Log-Level: debug), which is then
+		// logged with the verbose field set. It is only honored for peers in TrustedProxies, invalid levels are flagged by level_override_invalid
+		LevelOverrideHeader string
+		// Networks of the peers allowed to use LevelOverrideHeader, matched against the address of the direct peer
+		TrustedProxies []netip.Prefix
+		// RouteLabels returned by LabelRoutes, logged as fields for the matched route
+		RouteLabels *RouteLabels
+		// FallbackLogger receives the entries, with fallback=true, that the logger core or the Sink failed to write
+		// (e.g. a network sink being unavailable). Their count is reported by Handle.Stats. The entries compacted by
+		// CompactRepeats are written in full, and a lost base is replaced by the next entry
+		FallbackLogger *zap.Logger
+		// Whether to log the requests whose handler panicked with panicked=true, the panic value and its stack.
+		// When echo's Recover is registered before the middleware, the panic is logged as a Server error then propagated to it.
+		// When Recover is registered after, it handles the panic and returns nil, so the panic is only logged when
+		// StashPanics is registered right after Recover
+		CapturePanics bool
+		// Context key where StashPanics, or a custom recover middleware, stores the PanicInfo. Defaults to DefaultPanicContextKey
+		PanicContextKey string
+		// Whether to log all the values of repeated headers, in request_headers and the trailers, as arrays instead of joining them
+		// or keeping the first one. It also flags repeated security relevant headers (Host, Content-Length, X-Forwarded-For...)
+		// with duplicate_headers=true
+		PreserveMultiValues bool
 	}
 )
 
+// HeaderXResponseTime is the response header set by StampResponseHeaders
+const HeaderXResponseTime = "X-Response-Time"
+
 var (
 	// DefaultZapLoggerConfig is the default ZapLogger middleware config.
 	DefaultZapLoggerConfig = ZapLoggerConfig{
-		Skipper:                  DefaultSkipper,
-		IncludeRequestLogMessage: false,
+		Skipper:                    DefaultSkipper,
+		IncludeRequestLogMessage:   false,
+		HealthCheckSummaryInterval: 60 * time.Second,
+		RequestLogMessageFunc:      DefaultRequestLogMessage,
 	}
 )
 
+// DefaultRequestLogMessage formats the request part of the message as ": GET /path"
+func DefaultRequestLogMessage(method, uri string) string {
+	return ": " + method + " " + uri
+}
+
 // DefaultSkipper returns false which processes the middleware
 func DefaultSkipper(echo.Context) bool {
 	return false
@@ -41,7 +267,91 @@ func ZapLogger(log *zap.Logger) echo.MiddlewareFunc {
 }
 
 // ZapLoggerWithConfig is a middleware (with configuration) and zap to provide an "access log" like logging for each request.
+// It panics when the configuration is invalid, see ZapLoggerConfig.Validate, or starts background work, which can only
+// be stopped through ZapLoggerWithHandle.
 func ZapLoggerWithConfig(log *zap.Logger, config ZapLoggerConfig) echo.MiddlewareFunc {
+	if len(config.HealthCheckPaths) > 0 || config.SummaryInterval > 0 {
+		panic(errors.New("echozap: HealthCheckPaths and SummaryInterval require ZapLoggerWithHandle and closing the Handle on shutdown"))
+	}
+	mw, _ := ZapLoggerWithHandle(log, config)
+	return mw
+}
+
+// ZapLoggerWithHandle is like ZapLoggerWithConfig but also returns a Handle controlling the middleware background work.
+// The handle should be closed on shutdown so pending summaries are flushed. It panics when the configuration is invalid,
+// see ZapLoggerConfig.Validate.
+func ZapLoggerWithHandle(log *zap.Logger, config ZapLoggerConfig) (echo.MiddlewareFunc, *Handle) {
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+	if config.AccessCore != nil {
+		log = zap.New(config.AccessCore)
+	}
+	if config.DisableCaller {
+		log = log.WithOptions(zap.WithCaller(false))
+	}
+	if len(config.Options) > 0 {
+		log = log.WithOptions(config.Options...)
+	}
+	if config.RequestLogMessageFunc == nil {
+		config.RequestLogMessageFunc = DefaultZapLoggerConfig.RequestLogMessageFunc
+	}
+	if config.PanicContextKey == "" {
+		config.PanicContextKey = DefaultPanicContextKey
+	}
+	if len(config.RetryAttemptHeaders) == 0 {
+		config.RetryAttemptHeaders = DefaultRetryAttemptHeaders
+	}
+	if config.HealthCheckSummaryInterval <= 0 {
+		config.HealthCheckSummaryInterval = DefaultZapLoggerConfig.HealthCheckSummaryInterval
+	}
+
+	fieldsFunc := config.FieldsFunc
+	if config.ExpensiveFieldsFunc != nil {
+		fieldsFunc = chainFieldsFuncs(FieldsFuncFromContext(config.ExpensiveFieldsFunc), config.FieldsFunc)
+	}
+
+	builtins, err := newFieldMask(config.OnlyFields, config.ExcludeFields)
+	if err != nil {
+		panic(err)
+	}
+
+	var fallbacks *atomic.Uint64
+	if config.FallbackLogger != nil {
+		log, fallbacks = withFallback(log, config.FallbackLogger)
+	}
+
+	policies, err := newPathPolicies(config.PathPolicies)
+	if err != nil {
+		panic(err)
+	}
+
+	groups, err := newFieldGroups(config.FieldGroups)
+	if err != nil {
+		panic(err)
+	}
+
+	var compactor *repeatCompactor
+	if config.CompactRepeats {
+		compactor = newRepeatCompactor(config.CompactFields, config.CompactResyncEvery)
+	}
+
+	handle := newHandle(log, config)
+	handle.fallbacks = fallbacks
+
+	contextFields := newContextFields(config.ContextFields)
+	encoders := newTypeEncoders(config.TypeEncoders)
+	fingerprinter := newClientFingerprinter(config.ClientFingerprint)
+
+	var anonymizer *ipAnonymizer
+	if config.IPAnonymization != IPAnonNone || config.IPAnonFunc != nil {
+		anonymizer = newIPAnonymizer(config.IPAnonSecret)
+	}
+	trusted := newTrustedNetworks(config.TrustedProxies)
+	synthetic := newSyntheticMatcher(config.SyntheticUserAgents)
+	responseTrailers := canonicalKeys(config.LogResponseTrailers)
+	requestTrailers := canonicalKeys(config.LogRequestTrailers)
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		// Defaults
 		if config.Skipper == nil {
@@ -49,57 +359,524 @@ func ZapLoggerWithConfig(log *zap.Logger, config ZapLoggerConfig) echo.Middlewar
 		}
 
 		return func(c echo.Context) error {
-			if config.Skipper(c) {
+			// hc is the context given to the hooks
+			hc := c
+			if config.Development {
+				guard := &guardedContext{Context: c}
+				defer guard.poison()
+				hc = guard
+			}
+
+			// Every request is counted on its connection, even when it is not logged
+			connRequest := countConnRequest(c.Request())
+
+			if config.Skipper(hc) {
+				if config.ExplainDecisions {
+					explainSuppressed(log, c.Request(), reasonSkipper)
+				}
 				return next(c)
 			}
 
-			start := time.Now()
+			isSynthetic := synthetic.matches(c.Request().UserAgent())
+			if isSynthetic && config.SkipSynthetic {
+				if config.ExplainDecisions {
+					explainSuppressed(log, c.Request(), reasonSynthetic)
+				}
+				return next(c)
+			}
+
+			start := handle.now()
+			c.Set(clockKey, handle.now)
+
+			verbose := sampleVerbose(requestID(c), config.VerboseSampleRate)
 
-			err := next(c)
+			var override levelOverride
+			if config.LevelOverrideHeader != "" {
+				override = parseLevelOverride(c.Request(), config.LevelOverrideHeader, trusted)
+				verbose = verbose || override.ok
+			}
+
+			var rate float64
+			var adaptive bool
+			if handle.errorRate != nil {
+				rate, adaptive = handle.errorRate.active()
+				verbose = verbose || adaptive && config.ErrorRate.Verbose
+			}
+
+			// The watchlist is evaluated once the handler returned, the body is captured for the requests it may match
+			mayWatch := config.WatchLogger != nil && handle.watchlist.mayMatch(hc)
+
+			var body *snippetReader
+			if (verbose || mayWatch) && config.VerboseFields.BodySnippetSize > 0 && c.Request().Body != nil {
+				body = &snippetReader{ReadCloser: c.Request().Body, limit: config.VerboseFields.BodySnippetSize}
+				c.Request().Body = body
+			}
+
+			if config.StampResponseHeaders {
+				stampResponseHeaders(c, start, handle.now)
+			}
+
+			var breakdown *latencyBreakdown
+			if config.IncludeLatencyBreakdown {
+				breakdown = newLatencyBreakdown(c, start, handle.now)
+			}
+
+			var writeTime *writeTimer
+			if config.WriteLatencyBudget > 0 {
+				writeTime = newWriteTimer(c.Response(), handle.now)
+			}
+
+			var writer *trackingWriter
+			if config.LogWriteErrors || config.NormalizeStatus {
+				writer = &trackingWriter{ResponseWriter: c.Response().Writer}
+				c.Response().Writer = writer.wrap()
+			}
+
+			var err error
+			// crossing is the panic propagated through the middleware, raised again once logged
+			var crossing *PanicInfo
+			if config.CapturePanics {
+				err = serveCapturingPanic(next, c, &crossing)
+			} else {
+				err = next(c)
+			}
 			if err != nil {
 				c.Error(err)
 			}
+			if crossing != nil {
+				defer panic(crossing.Value)
+				err = crossing.err()
+			}
 
 			req := c.Request()
 			res := c.Response()
 
-			requestLogField := fmt.Sprintf("%s %s", req.Method, req.RequestURI)
+			if writer != nil {
+				res.Writer = writer.ResponseWriter
+			}
+
+			end := handle.now()
+
+			// The values are computed once, so the fields, the hooks and the emitters cannot disagree
+			v := Values{
+				Start:     start,
+				Latency:   end.Sub(start),
+				Status:    res.Status,
+				Size:      res.Size,
+				RequestID: requestID(c),
+				Method:    req.Method,
+				URI:       req.RequestURI,
+				Path:      req.URL.Path,
+				Host:      req.Host,
+				UserAgent: req.UserAgent(),
+				Err:       err,
+			}
+
+			if crossing != nil && !res.Committed {
+				// The response is written by the recover middleware
+				v.Status = http.StatusInternalServerError
+			}
+
+			var statusFields []zapcore.Field
+			if config.NormalizeStatus {
+				if status := normalizeStatus(res.Status, writer, err); status != res.Status {
+					v.Status = status
+					statusFields = append(statusFields, zap.Int("status_raw", res.Status))
+				}
+			}
+
+			var latencyFields []zapcore.Field
+			v.Latency, latencyFields = checkLatency(v.Latency, config.MaxPlausibleLatency)
+
+			if handle.errorRate != nil {
+				handle.errorRate.record(v.Status >= http.StatusInternalServerError)
+			}
+
+			if handle.summary != nil {
+				handle.summary.record(v.Latency, v.Status)
+			}
+
+			if handle.health != nil && handle.health.record(v.Path, v.Latency, v.Status) {
+				if config.ExplainDecisions {
+					explainSuppressed(log, req, reasonHealthCheck)
+				}
+				return nil
+			}
+
+			var policy *pathPolicy
+			if policies != nil {
+				if policy = policies.match(c); policy != nil {
+					if reason := policy.drop(v.Status, v.RequestID, end); reason != "" {
+						if config.ExplainDecisions {
+							explainSuppressed(log, req, reason)
+						}
+						return nil
+					}
+				}
+			}
+
+			anonMode := config.IPAnonymization
+			if config.IPAnonFunc != nil {
+				anonMode = config.IPAnonFunc(hc)
+			}
+
+			var realIPFields []zapcore.Field
+			if config.ValidateRealIP {
+				v.RemoteIP, realIPFields = validatedRealIP(c, anonymizer, anonMode)
+			} else {
+				v.RemoteIP = c.RealIP()
+			}
+			// The fingerprint masks and hashes the IP itself
+			clientIP := v.RemoteIP
+			if anonymizer != nil {
+				v.RemoteIP = anonymizer.ip(anonMode, v.RemoteIP)
+			}
+
+			fields := buildFields(req, v.Status, v.Size, v.Latency, v.RequestID, v.RemoteIP, builtins)
+			fields = append(fields, realIPFields...)
+			fields = append(fields, statusFields...)
+			fields = append(fields, latencyFields...)
+
+			if config.PreserveMultiValues {
+				fields = append(fields, duplicateHeaderFields(req.Header)...)
+			}
+
+			if override.invalid {
+				fields = append(fields, zap.Bool("level_override_invalid", true))
+			}
+
+			if isSynthetic && config.TagSynthetic {
+				fields = append(fields, zap.Bool("synthetic", true))
+			}
+
+			if adaptive {
+				fields = append(fields, zap.Float64("error_rate", rate))
+			}
+
+			var logicalStatus int
+			var hasLogicalStatus bool
+			if config.LogicalStatusFunc != nil {
+				logicalStatus, hasLogicalStatus = config.LogicalStatusFunc(hc)
+				if hasLogicalStatus {
+					fields = append(fields, zap.Int("logical_status", logicalStatus))
+				}
+			}
+
+			if config.IncludeRedirectLocation && isRedirect(v.Status) {
+				if location := res.Header().Get(echo.HeaderLocation); location != "" {
+					fields = append(fields, zap.String("location", location))
+				}
+			}
+
+			if config.IncludeResponseContentType {
+				fields = append(fields, contentTypeFields(res.Header().Get(echo.HeaderContentType))...)
+			}
+
+			if config.IncludeConditionalRequestInfo {
+				fields = append(fields, conditionalFields(req, res)...)
+			}
+
+			if len(responseTrailers) > 0 {
+				fields = append(fields, trailerFields("trailers", res.Header(), responseTrailers, config.PreserveMultiValues)...)
+			}
+
+			if len(requestTrailers) > 0 {
+				fields = append(fields, trailerFields("request_trailers", req.Trailer, requestTrailers, config.PreserveMultiValues)...)
+			}
+
+			if config.Priority.enabled() {
+				fields = append(fields, config.Priority.fields(c)...)
+			}
+
+			if len(config.APIVersionExtractors) > 0 {
+				fields = append(fields, apiVersionFields(hc, config.APIVersionExtractors)...)
+			}
+
+			if config.IdempotencyKeyHeader != "" {
+				fields = append(fields, idempotencyKeyFields(req.Header.Get(config.IdempotencyKeyHeader))...)
+			}
+
+			if config.IncludeLocale {
+				fields = append(fields, localeFields(req.Header)...)
+			}
+
+			if config.IncludeRetryMetadata {
+				fields = append(fields, retryFields(req.Header, config.RetryAttemptHeaders)...)
+			}
+
+			if config.RouteLabels != nil {
+				fields = append(fields, config.RouteLabels.lookup(c)...)
+			}
+
+			if fingerprinter != nil {
+				fields = append(fields, fingerprinter.fields(clientIP, v.UserAgent)...)
+			}
+
+			fields = append(fields, connFields(connRequest, req)...)
+			unclosedPhases := closePhases(c)
+			fields = append(fields, encoders.encode(canonicalFields(c))...)
+			fields = append(fields, unclosedPhases...)
+
+			if len(contextFields) > 0 {
+				fields = append(fields, encoders.encode(contextFieldsFields(c, contextFields, config.ContextFieldsStringify, encoders))...)
+			}
+
+			if config.CapturePanics {
+				p := crossing
+				if p == nil {
+					p = recoveredPanic(c, config.PanicContextKey)
+				}
+				if p != nil {
+					fields = append(fields, p.fields()...)
+				}
+			}
+
+			if config.ErrorFingerprint && err != nil && v.Status >= http.StatusInternalServerError {
+				fields = append(fields, errorFingerprintFields(err)...)
+			}
+
+			if config.LogBindErrors && err != nil {
+				fields = append(fields, bindErrorFields(err)...)
+			}
+
+			if breakdown != nil {
+				fields = append(fields, breakdown.fields(c, end)...)
+			}
 
-			fields := []zapcore.Field{
-				zap.String("remote_ip", c.RealIP()),
-				zap.String("latency", time.Since(start).String()),
-				zap.String("host", req.Host),
-				zap.String("request", requestLogField),
-				zap.Int("status", res.Status),
-				zap.Int64("size", res.Size),
-				zap.String("user_agent", req.UserAgent()),
+			if config.LogWriteErrors && writer.err != nil {
+				fields = append(fields, writer.fields(res)...)
 			}
 
-			id := req.Header.Get(echo.HeaderXRequestID)
-			if id == "" {
-				id = res.Header().Get(echo.HeaderXRequestID)
+			var slowWriteFields []zapcore.Field
+			if writeTime != nil {
+				slowWriteFields = writeTime.fields(config.WriteLatencyBudget)
+				fields = append(fields, slowWriteFields...)
 			}
-			fields = append(fields, zap.String("request_id", id))
 
 			var requestLogMessage string
 
-			if config.IncludeRequestLogMessage {
-				requestLogMessage = ": " + requestLogField
+			if config.MessageIncludesRoute {
+				requestLogMessage = " " + v.Method + " " + messageRoute(c)
+			} else if config.IncludeRequestLogMessage {
+				uri := v.URI
+				if config.MessageUsesRoute && c.Path() != "" {
+					uri = c.Path()
+				}
+				requestLogMessage = config.RequestLogMessageFunc(v.Method, sanitizeMessageURI(uri))
 			}
 
-			n := res.Status
+			// levelReason is the reason of the level raised above the status level, for ExplainDecisions
+			var levelReason string
+
+			level, msg := statusLevel(v.Status)
+			if config.LevelFromLogicalStatus && hasLogicalStatus {
+				if l, m := logicalStatusLevel(logicalStatus); l > level {
+					level, msg, levelReason = l, m, reasonLogicalStatus
+				}
+			}
+			if level >= zapcore.WarnLevel && builtins.has(fieldError) {
+				fields = append([]zapcore.Field{zap.Error(err)}, fields...)
+			}
+
+			if config.WarnOnWriteError && config.LogWriteErrors && writer.err != nil && level < zapcore.WarnLevel {
+				level, levelReason = zapcore.WarnLevel, reasonWriteError
+			}
+
+			if config.WarnOnSlowWrite && slowWriteFields != nil && level < zapcore.WarnLevel {
+				level, levelReason = zapcore.WarnLevel, reasonSlowWrite
+			}
+
+			if override.ok && override.level > level {
+				level, levelReason = override.level, reasonLevelOverride
+			}
+
+			v.Level, v.Message = level, msg+requestLogMessage
+			if config.LevelFunc != nil {
+				if v.Level = config.LevelFunc(hc, v); v.Level != level {
+					levelReason = reasonLevelFunc
+				}
+			}
+			if config.MessageFunc != nil {
+				v.Message = config.MessageFunc(hc, v)
+			}
+
+			if config.ExplainDecisions {
+				if levelReason == "" {
+					levelReason = statusReason(v.Status)
+				}
+				fields = append(fields, decisionField(v.Level, levelReason, verbose, policy != nil))
+			}
+
+			// Whether the entry is written by the sink or EmitFunc, instead of ce
+			var delegated bool
+			var ce *zapcore.CheckedEntry
 			switch {
-			case n >= 500:
-				log.With(zap.Error(err)).Error("Server error"+requestLogMessage, fields...)
-			case n >= 400:
-				log.With(zap.Error(err)).Warn("Client error"+requestLogMessage, fields...)
-			case n >= 300:
-				log.Info("Redirection"+requestLogMessage, fields...)
+			case config.Sink != nil:
+				delegated = config.Sink.Enabled(hc, v.Level)
+			case config.EmitFunc != nil:
+				delegated = true
 			default:
-				log.Info("Success"+requestLogMessage, fields...)
+				ce = log.Check(v.Level, v.Message)
+			}
+
+			var wce *zapcore.CheckedEntry
+			if config.WatchLogger != nil && handle.watchlist.matches(hc) {
+				wce = config.WatchLogger.Check(v.Level, v.Message)
+			}
+
+			if ce == nil && wce == nil && len(config.Emitters) == 0 && !delegated {
+				return nil
+			}
+
+			// Expensive fields are only computed for entries a logger core or an emitter accepts
+			var verboseFields []zapcore.Field
+			if verbose || wce != nil {
+				header := req.Header
+				if anonymizer != nil {
+					header = anonymizer.header(anonMode, header)
+				}
+				verboseFields = config.VerboseFields.fields(req, header, body, config.PreserveMultiValues)
+			}
+
+			if verbose {
+				fields = append(fields, zap.Bool("verbose_sample", true))
+				fields = append(fields, verboseFields...)
+			}
+
+			if fieldsFunc != nil {
+				v.Fields = fields
+				fields = append(fields, encoders.encode(fieldsFunc(hc, v))...)
+			}
+
+			if config.MaxEntryBytes > 0 {
+				fields = trimEntry(v.Message, fields, config.MaxEntryBytes)
+			}
+
+			if groups != nil {
+				fields = groups.apply(fields)
+			}
+
+			write := func(written []zapcore.Field) bool {
+				if delegated && config.Sink != nil {
+					v.Fields = written
+					err := safeEmit(config.Sink, hc, v)
+					if err != nil {
+						// The fallback output holds none of the bases, so it receives the complete fields
+						if config.FallbackLogger != nil {
+							err = writeSinkFallback(config.FallbackLogger, fallbacks, v, fields, err)
+						}
+						emitFailed(log, config.Sink, err)
+					}
+					return err == nil
+				}
+
+				// Likewise, the fallback core receives the complete fields of a compacted entry
+				var lost *lostEntry
+				if compactor != nil && config.FallbackLogger != nil {
+					lost = &lostEntry{fields: fields}
+					written = append(written[:len(written):len(written)], lost.field())
+				}
+				if delegated {
+					config.EmitFunc(log, v.Level, v.Message, written)
+				} else if ce != nil {
+					ce.Write(written...)
+				}
+				return lost == nil || !lost.failed
+			}
+
+			// Only the entries written to the output are compacted, so they are the only bases
+			if compactor != nil && (delegated || ce != nil) {
+				compactor.compact(fields, write)
+			} else {
+				write(fields)
+			}
+
+			if wce != nil {
+				watchFields := append(fields[:len(fields):len(fields)], zap.Bool("watched", true))
+				if !verbose {
+					watchFields = append(watchFields, verboseFields...)
+				}
+				wce.Write(watchFields...)
+			}
+
+			if len(config.Emitters) > 0 {
+				v.Fields = fields
+				emit(log, config.Emitters, hc, v)
 			}
 
 			return nil
 		}
+	}, handle
+}
+
+// statusLevel returns the level and message used to log a response with the given status
+func statusLevel(n int) (zapcore.Level, string) {
+	switch {
+	case n >= 500:
+		return zapcore.ErrorLevel, "Server error"
+	case n >= 400:
+		return zapcore.WarnLevel, "Client error"
+	case n >= 300:
+		return zapcore.InfoLevel, "Redirection"
+	default:
+		return zapcore.InfoLevel, "Success"
+	}
+}
+
+// stampResponseHeaders sets the request ID and the time to first byte on the response before the headers are written
+func stampResponseHeaders(c echo.Context, start time.Time, now func() time.Time) {
+	res := c.Response()
+	res.Before(func() {
+		if id := requestID(c); id != "" {
+			res.Header().Set(echo.HeaderXRequestID, id)
+		}
+		res.Header().Set(HeaderXResponseTime, now().Sub(start).String())
+	})
+}
+
+// isRedirect reports whether the status is a redirect carrying a Location header
+func isRedirect(n int) bool {
+	switch n {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// requestID returns the request ID from the request header, falling back to the one set on the response
+func requestID(c echo.Context) string {
+	id := c.Request().Header.Get(echo.HeaderXRequestID)
+	if id == "" {
+		id = c.Response().Header().Get(echo.HeaderXRequestID)
+	}
+	return id
+}
+
+// maxMessageURILength is the maximum number of bytes of the URI included in the message
+const maxMessageURILength = 256
+
+// sanitizeMessageURI removes the control characters from uri and caps its length
+func sanitizeMessageURI(uri string) string {
+	if strings.IndexFunc(uri, unicode.IsControl) >= 0 {
+		uri = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, uri)
+	}
+	uri, _ = truncate(uri, maxMessageURILength)
+	return uri
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence, and reports whether it was cut
+func truncate(s string, n int) (string, bool) {
+	if len(s) <= n {
+		return s, false
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
 	}
+	return s[:n], true
 }
@@ -5,10 +5,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
 )
 
@@ -64,3 +66,241 @@ func TestZapLoggerWithConfig(t *testing.T) {
 
 	assert.Equal(t, 0, logs.Len())
 }
+
+func TestZapLoggerExpensiveFieldsFunc(t *testing.T) {
+	e := echo.New()
+
+	obs, logs := observer.New(zap.ErrorLevel)
+
+	logger := zap.New(obs)
+
+	calls := 0
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		ExpensiveFieldsFunc: func(c echo.Context) []zapcore.Field {
+			calls++
+			return []zapcore.Field{zap.String("route", c.Path())}
+		},
+	})
+
+	for _, status := range []int{http.StatusOK, http.StatusInternalServerError} {
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/something")
+
+		h := func(c echo.Context) error {
+			return c.String(status, "")
+		}
+
+		assert.Nil(t, mw(h)(c))
+		assert.Equal(t, status/500, calls)
+	}
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "/something", logs.AllUntimed()[0].ContextMap()["route"])
+}
+
+func TestZapLoggerWithAccessCore(t *testing.T) {
+	e := echo.New()
+
+	appObs, appLogs := observer.New(zap.ErrorLevel)
+	accessObs, accessLogs := observer.New(zap.InfoLevel)
+
+	logger := zap.New(appObs)
+
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		AccessCore: accessObs,
+	})
+
+	for _, status := range []int{http.StatusOK, http.StatusInternalServerError} {
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			logger.Info("application info")
+			logger.Error("application error")
+			return c.String(status, "")
+		}
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	assert.Equal(t, 2, appLogs.Len())
+	assert.Equal(t, 2, appLogs.FilterMessage("application error").Len())
+
+	assert.Equal(t, 2, accessLogs.Len())
+	assert.Equal(t, "Success", accessLogs.AllUntimed()[0].Message)
+	assert.Equal(t, "Server error", accessLogs.AllUntimed()[1].Message)
+}
+
+func TestZapLoggerRedirectLocation(t *testing.T) {
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{IncludeRedirectLocation: true})
+
+	handlers := []echo.HandlerFunc{
+		func(c echo.Context) error {
+			return c.Redirect(http.StatusFound, "/login?next=/something")
+		},
+		func(c echo.Context) error {
+			return c.NoContent(http.StatusFound)
+		},
+		func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderLocation, "/created")
+			return c.NoContent(http.StatusCreated)
+		},
+	}
+
+	for _, h := range handlers {
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	entries := logs.AllUntimed()
+
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, "/login?next=/something", entries[0].ContextMap()["location"])
+	assert.NotContains(t, entries[1].ContextMap(), "location")
+	assert.NotContains(t, entries[2].ContextMap(), "location")
+}
+
+func TestZapLoggerStampResponseHeaders(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	req.Header.Set(echo.HeaderXRequestID, "abc-123")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{StampResponseHeaders: true})(h)(c)
+
+	assert.Nil(t, err)
+
+	logFields := logs.AllUntimed()[0].ContextMap()
+
+	assert.Equal(t, "abc-123", rec.Header().Get(echo.HeaderXRequestID))
+	assert.Equal(t, logFields["request_id"], rec.Header().Get(echo.HeaderXRequestID))
+
+	ttfb, err := time.ParseDuration(rec.Header().Get(HeaderXResponseTime))
+	assert.Nil(t, err)
+
+	latency, err := time.ParseDuration(logFields["latency"].(string))
+	assert.Nil(t, err)
+	assert.True(t, ttfb <= latency)
+}
+
+func TestZapLoggerStampResponseHeadersClock(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{StampResponseHeaders: true})
+	defer handle.Close()
+
+	clock := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	handle.now = func() time.Time { return clock }
+
+	h := func(c echo.Context) error {
+		clock = clock.Add(30 * time.Millisecond)
+		return c.String(http.StatusOK, "")
+	}
+
+	assert.Nil(t, mw(h)(c))
+
+	// The header and the entry are measured with the same clock
+	assert.Equal(t, "30ms", rec.Header().Get(HeaderXResponseTime))
+	assert.Equal(t, "30ms", logs.AllUntimed()[0].ContextMap()["latency"])
+}
+
+func TestZapLoggerRequestLogMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		config  ZapLoggerConfig
+		message string
+	}{
+		{
+			name:    "default",
+			uri:     "/users/42?expand=true",
+			config:  ZapLoggerConfig{IncludeRequestLogMessage: true},
+			message: "Success: GET /users/42?expand=true",
+		},
+		{
+			name: "custom func",
+			uri:  "/users/42",
+			config: ZapLoggerConfig{
+				IncludeRequestLogMessage: true,
+				RequestLogMessageFunc: func(method, uri string) string {
+					return " [" + method + "] " + uri
+				},
+			},
+			message: "Success [GET] /users/42",
+		},
+		{
+			name:    "route",
+			uri:     "/users/42",
+			config:  ZapLoggerConfig{IncludeRequestLogMessage: true, MessageUsesRoute: true},
+			message: "Success: GET /users/:id",
+		},
+		{
+			name:    "control characters",
+			uri:     "/users/42?name=a\nSuccess: GET /admin\r\x00",
+			config:  ZapLoggerConfig{IncludeRequestLogMessage: true},
+			message: "Success: GET /users/42?name=aSuccess: GET /admin",
+		},
+		{
+			name:    "over-long",
+			uri:     "/" + strings.Repeat("a", 300),
+			config:  ZapLoggerConfig{IncludeRequestLogMessage: true},
+			message: "Success: GET /" + strings.Repeat("a", 255),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+			req.RequestURI = tt.uri
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/users/:id")
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, tt.config)(h)(c)
+
+			assert.Nil(t, err)
+
+			entry := logs.AllUntimed()[0]
+
+			assert.Equal(t, tt.message, entry.Message)
+			assert.Equal(t, "GET "+tt.uri, entry.ContextMap()["request"])
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package echozap
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogicalStatusFromHeader returns a LogicalStatusFunc reading the status from the given response header
+// (e.g. Grpc-Status or X-App-Error-Code)
+func LogicalStatusFromHeader(name string) func(c echo.Context) (int, bool) {
+	return func(c echo.Context) (int, bool) {
+		v := c.Response().Header().Get(name)
+		if v == "" {
+			return 0, false
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, false
+		}
+		return code, true
+	}
+}
+
+// logicalStatusLevel returns the level and message for a logical status.
+// Codes below 100 are gRPC codes, other codes are interpreted as HTTP statuses.
+func logicalStatusLevel(code int) (zapcore.Level, string) {
+	if code >= 100 {
+		return statusLevel(code)
+	}
+
+	switch code {
+	case 0: // OK
+		return zapcore.InfoLevel, "Success"
+	case 1, 3, 5, 6, 7, 9, 11, 16: // Canceled, InvalidArgument, NotFound, AlreadyExists, PermissionDenied, FailedPrecondition, OutOfRange, Unauthenticated
+		return zapcore.WarnLevel, "Client error"
+	default:
+		return zapcore.ErrorLevel, "Server error"
+	}
+}
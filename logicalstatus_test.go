@@ -0,0 +1,66 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerLogicalStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		override bool
+		logical  interface{}
+		level    zapcore.Level
+		message  string
+	}{
+		{name: "header present", header: "13", logical: int64(13), level: zapcore.InfoLevel, message: "Success"},
+		{name: "header absent", level: zapcore.InfoLevel, message: "Success"},
+		{name: "invalid header", header: "internal", level: zapcore.InfoLevel, message: "Success"},
+		{name: "level override", header: "13", override: true, logical: int64(13), level: zapcore.ErrorLevel, message: "Server error"},
+		{name: "client code override", header: "5", override: true, logical: int64(5), level: zapcore.WarnLevel, message: "Client error"},
+		{name: "ok code override", header: "0", override: true, logical: int64(0), level: zapcore.InfoLevel, message: "Success"},
+		{name: "http code override", header: "503", override: true, logical: int64(503), level: zapcore.ErrorLevel, message: "Server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/rpc", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				if tt.header != "" {
+					c.Response().Header().Set("Grpc-Status", tt.header)
+				}
+				return c.String(http.StatusOK, "{}")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+				LogicalStatusFunc:      LogicalStatusFromHeader("Grpc-Status"),
+				LevelFromLogicalStatus: tt.override,
+			})(h)(c)
+
+			assert.Nil(t, err)
+
+			entry := logs.AllUntimed()[0]
+
+			assert.Equal(t, tt.level, entry.Level)
+			assert.Equal(t, tt.message, entry.Message)
+			assert.Equal(t, tt.logical, entry.ContextMap()["logical_status"])
+			assert.Equal(t, int64(http.StatusOK), entry.ContextMap()["status"])
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package echozap
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// securityHeaders are the request headers expected once, whose repetition may be a smuggling or spoofing attempt
+var securityHeaders = []string{
+	"Host",
+	"Content-Length",
+	"Transfer-Encoding",
+	"Authorization",
+	"X-Forwarded-For",
+	"X-Real-Ip",
+	"X-Request-Id",
+}
+
+// duplicateHeaderFields returns duplicate_headers=true when a security relevant header appears more than once
+func duplicateHeaderFields(h http.Header) []zapcore.Field {
+	for _, k := range securityHeaders {
+		if len(h[k]) > 1 {
+			return []zapcore.Field{zap.Bool("duplicate_headers", true)}
+		}
+	}
+	return nil
+}
+
+// multiHeaderMarshaler logs HTTP headers as an object holding all the values of each header as an array
+type multiHeaderMarshaler http.Header
+
+func (h multiHeaderMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range h {
+		if err := enc.AddArray(k, stringArray(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type stringArray []string
+
+func (a stringArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, s := range a {
+		enc.AppendString(s)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package echozap
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerPreserveMultiValues(t *testing.T) {
+	raw := "GET /something?id=1&id=2 HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"X-Forwarded-For: 198.51.100.7\r\n" +
+		"X-Forwarded-For: 203.0.113.9\r\n" +
+		"Accept: application/json\r\n" +
+		"\r\n"
+
+	serve := func(preserve bool) map[string]interface{} {
+		req, err := http.ReadRequest(bufio.NewReader(strings.NewReader(raw)))
+		assert.Nil(t, err)
+		req.RemoteAddr = "192.0.2.1:1234"
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			assert.Equal(t, []string{"1", "2"}, c.QueryParams()["id"])
+			return c.String(http.StatusOK, "")
+		}
+
+		obs, logs := observer.New(zap.DebugLevel)
+
+		logger := zap.New(obs)
+
+		assert.Nil(t, ZapLoggerWithConfig(logger, ZapLoggerConfig{
+			VerboseSampleRate:   1,
+			VerboseFields:       VerboseFieldsConfig{Headers: true},
+			PreserveMultiValues: preserve,
+		})(h)(c))
+
+		assert.Equal(t, 1, logs.Len())
+		return logs.AllUntimed()[0].ContextMap()
+	}
+
+	fields := serve(false)
+	assert.Equal(t, "GET /something?id=1&id=2", fields["request"])
+	assert.Equal(t, "198.51.100.7, 203.0.113.9", fields["request_headers"].(map[string]interface{})["X-Forwarded-For"])
+	assert.NotContains(t, fields, "duplicate_headers")
+
+	fields = serve(true)
+	assert.Equal(t, "GET /something?id=1&id=2", fields["request"])
+	headers := fields["request_headers"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"198.51.100.7", "203.0.113.9"}, headers["X-Forwarded-For"])
+	assert.Equal(t, []interface{}{"application/json"}, headers["Accept"])
+	assert.Equal(t, true, fields["duplicate_headers"])
+}
+
+func TestDuplicateHeaderFields(t *testing.T) {
+	assert.Nil(t, duplicateHeaderFields(http.Header{"Accept": {"a", "b"}, "Host": {"example.com"}}))
+	assert.NotNil(t, duplicateHeaderFields(http.Header{"Content-Length": {"1", "100"}}))
+	assert.NotNil(t, duplicateHeaderFields(http.Header{"Host": {"example.com", "internal"}}))
+}
+
+func TestTrailerFieldsMultiValues(t *testing.T) {
+	header := http.Header{"Grpc-Message": {"first", "second"}}
+
+	obs, logs := observer.New(zap.DebugLevel)
+	zap.New(obs).Info("trailers",
+		trailerFields("compact", header, []string{"Grpc-Message"}, false)[0],
+		trailerFields("full", header, []string{"Grpc-Message"}, true)[0])
+
+	fields := logs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, "first", fields["compact"].(map[string]interface{})["Grpc-Message"])
+	assert.Equal(t, []interface{}{"first", "second"}, fields["full"].(map[string]interface{})["Grpc-Message"])
+}
@@ -0,0 +1,75 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerCallerOptions(t *testing.T) {
+	var hooked int
+
+	tests := []struct {
+		name   string
+		config ZapLoggerConfig
+		caller string
+	}{
+		{
+			name:   "default",
+			config: ZapLoggerConfig{},
+			caller: "logger.go",
+		},
+		{
+			name:   "disabled",
+			config: ZapLoggerConfig{DisableCaller: true},
+		},
+		{
+			name: "caller skip",
+			config: ZapLoggerConfig{Options: []zap.Option{
+				zap.AddCallerSkip(1),
+				zap.Hooks(func(zapcore.Entry) error {
+					hooked++
+					return nil
+				}),
+			}},
+			caller: "options_test.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs, zap.Development(), zap.AddCaller())
+
+			assert.Nil(t, ZapLoggerWithConfig(logger, tt.config)(h)(c))
+
+			assert.Equal(t, 1, logs.Len())
+			caller := logs.AllUntimed()[0].Caller
+			if tt.caller == "" {
+				assert.False(t, caller.Defined)
+				return
+			}
+			assert.True(t, caller.Defined)
+			assert.Equal(t, tt.caller, filepath.Base(caller.File))
+		})
+	}
+
+	assert.Equal(t, 1, hooked)
+}
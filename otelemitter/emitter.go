@@ -0,0 +1,112 @@
+// Package otelemitter exports the echozap access log entries as OpenTelemetry log records.
+package otelemitter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Unity-Technologies/echozap"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// Emitter is an echozap.Emitter writing each entry to an OpenTelemetry logger.
+// Records are emitted with the request context, so they are correlated with the active trace.
+type Emitter struct {
+	logger log.Logger
+}
+
+// New returns an Emitter writing to logger
+func New(logger log.Logger) *Emitter {
+	return &Emitter{logger: logger}
+}
+
+// Emit writes the entry as a log record whose body is the message and attributes are the entry fields
+func (e *Emitter) Emit(c echo.Context, v echozap.Values) error {
+	var r log.Record
+	r.SetTimestamp(v.Start.Add(v.Latency))
+	r.SetObservedTimestamp(time.Now())
+	r.SetSeverity(severity(v.Level))
+	r.SetSeverityText(v.Level.CapitalString())
+	r.SetBody(log.StringValue(v.Message))
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range v.Fields {
+		f.AddTo(enc)
+	}
+	r.AddAttributes(keyValues(enc.Fields)...)
+
+	e.logger.Emit(c.Request().Context(), r)
+	return nil
+}
+
+func severity(l zapcore.Level) log.Severity {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return log.SeverityDebug
+	case l == zapcore.InfoLevel:
+		return log.SeverityInfo
+	case l == zapcore.WarnLevel:
+		return log.SeverityWarn
+	case l == zapcore.ErrorLevel:
+		return log.SeverityError
+	default:
+		return log.SeverityFatal
+	}
+}
+
+// keyValues converts the encoded fields to attributes, sorted by key
+func keyValues(m map[string]interface{}) []log.KeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]log.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, log.KeyValue{Key: k, Value: value(m[k])})
+	}
+	return kvs
+}
+
+func value(v interface{}) log.Value {
+	switch v := v.(type) {
+	case string:
+		return log.StringValue(v)
+	case bool:
+		return log.BoolValue(v)
+	case int:
+		return log.IntValue(v)
+	case int64:
+		return log.Int64Value(v)
+	case int32:
+		return log.Int64Value(int64(v))
+	case uint64:
+		return log.Int64Value(int64(v))
+	case uint32:
+		return log.Int64Value(int64(v))
+	case float64:
+		return log.Float64Value(v)
+	case float32:
+		return log.Float64Value(float64(v))
+	case []byte:
+		return log.BytesValue(v)
+	case time.Duration:
+		return log.StringValue(v.String())
+	case time.Time:
+		return log.StringValue(v.Format(time.RFC3339Nano))
+	case []interface{}:
+		values := make([]log.Value, 0, len(v))
+		for _, e := range v {
+			values = append(values, value(e))
+		}
+		return log.SliceValue(values...)
+	case map[string]interface{}:
+		return log.MapValue(keyValues(v)...)
+	default:
+		return log.StringValue(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,59 @@
+package otelemitter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Unity-Technologies/echozap"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/logtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEmitter(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	req.Header.Set(echo.HeaderXRequestID, "abc-123")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusInternalServerError, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	recorder := logtest.NewRecorder()
+
+	err := echozap.ZapLoggerWithConfig(logger, echozap.ZapLoggerConfig{
+		Emitters: []echozap.Emitter{New(recorder.Logger("echozap"))},
+	})(h)(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, logs.Len())
+
+	records := recorder.Result()[0].Records
+	assert.Equal(t, 1, len(records))
+
+	r := records[0]
+	assert.Equal(t, "Server error", r.Body().AsString())
+	assert.Equal(t, log.SeverityError, r.Severity())
+	assert.Equal(t, "ERROR", r.SeverityText())
+
+	attrs := map[string]log.Value{}
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+
+	assert.Equal(t, int64(http.StatusInternalServerError), attrs["status"].AsInt64())
+	assert.Equal(t, "abc-123", attrs["request_id"].AsString())
+	assert.Equal(t, "GET /something", attrs["request"].AsString())
+	assert.Equal(t, len(logs.AllUntimed()[0].ContextMap()), len(attrs))
+}
@@ -0,0 +1,85 @@
+package echozap
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultPanicContextKey is the context key used by StashPanics and CapturePanics when none is configured
+const DefaultPanicContextKey = "echozap.panic"
+
+// maxPanicStackSize is the maximum number of bytes of the stack of a panic, the default of echo's Recover
+const maxPanicStackSize = 4 << 10
+
+// PanicInfo describes a recovered panic
+type PanicInfo struct {
+	Value interface{}
+	Stack []byte
+}
+
+// StashPanics returns a middleware storing the panics of the next handlers in the context under key before
+// propagating them, so CapturePanics can log them. It is registered right after echo's Recover, which handles the
+// panic and returns nil: without StashPanics, the middleware only sees a Server error.
+func StashPanics(key string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			defer func() {
+				if r := recover(); r != nil {
+					c.Set(key, &PanicInfo{Value: r, Stack: panicStack()})
+					panic(r)
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+// serveCapturingPanic calls next, recovering its panic into p
+func serveCapturingPanic(next echo.HandlerFunc, c echo.Context, p **PanicInfo) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			*p = &PanicInfo{Value: r, Stack: panicStack()}
+		}
+	}()
+	return next(c)
+}
+
+func panicStack() []byte {
+	stack := make([]byte, maxPanicStackSize)
+	return stack[:runtime.Stack(stack, false)]
+}
+
+// recoveredPanic returns the panic recovered by a middleware after echozap and stashed in the context under key.
+// It returns nil when the handler did not panic, or when the panic was not stashed.
+func recoveredPanic(c echo.Context, key string) *PanicInfo {
+	switch p := c.Get(key).(type) {
+	case *PanicInfo:
+		return p
+	case PanicInfo:
+		return &p
+	}
+	return nil
+}
+
+// err returns the panic value as an error
+func (p *PanicInfo) err() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", p.Value)
+}
+
+func (p *PanicInfo) fields() []zapcore.Field {
+	fields := []zapcore.Field{
+		zap.Bool("panicked", true),
+		zap.String("panic", fmt.Sprint(p.Value)),
+	}
+	if len(p.Stack) > 0 {
+		fields = append(fields, zap.ByteString("stack", p.Stack))
+	}
+	return fields
+}
@@ -0,0 +1,31 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerCapturePanicsPropagates(t *testing.T) {
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/something", nil), httptest.NewRecorder())
+
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{CapturePanics: true})
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = mw(func(c echo.Context) error {
+			panic("boom")
+		})(c)
+	})
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "boom", logs.AllUntimed()[0].ContextMap()["error"])
+}
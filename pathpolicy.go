@@ -0,0 +1,170 @@
+package echozap
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PathPolicy overrides the logging of the requests it matches. Exactly one of Path, Prefix and Route is set
+type PathPolicy struct {
+	// Path matched exactly against the request path
+	Path string
+	// Prefix matched against the start of the request path
+	Prefix string
+	// Route template matched against the route of the request (e.g. /users/:id)
+	Route string
+	// Fraction of the matching requests logged (0 to 1), derived from the request ID like VerboseSampleRate. Zero logs all of them
+	SampleRate float64
+	// Maximum number of entries logged per second. Zero disables the limit
+	RateLimit int64
+	// Minimum status of the logged entries, e.g. 400 to log errors only
+	MinStatus int
+}
+
+// pathPolicies finds the first policy matching a request in the configuration order.
+// Exact paths and routes are looked up in maps and prefixes in a trie, so the cost does not grow with the number of policies.
+type pathPolicies struct {
+	policies []pathPolicy
+	paths    map[string]int
+	routes   map[string]int
+	prefixes *prefixNode
+}
+
+type pathPolicy struct {
+	PathPolicy
+	limiter rateLimiter
+}
+
+// prefixNode is a node of a byte trie, policy is the index of the policy whose prefix ends there or -1
+type prefixNode struct {
+	children map[byte]*prefixNode
+	policy   int
+}
+
+func newPathPolicies(policies []PathPolicy) (*pathPolicies, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	p := &pathPolicies{
+		policies: make([]pathPolicy, len(policies)),
+		paths:    make(map[string]int),
+		routes:   make(map[string]int),
+		prefixes: &prefixNode{policy: -1},
+	}
+
+	for i, policy := range policies {
+		set := 0
+		for _, s := range []string{policy.Path, policy.Prefix, policy.Route} {
+			if s != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("echozap: PathPolicies[%d] must set exactly one of Path, Prefix and Route", i)
+		}
+		if policy.SampleRate < 0 || policy.SampleRate > 1 {
+			return nil, fmt.Errorf("echozap: PathPolicies[%d] has a SampleRate outside of 0 to 1", i)
+		}
+
+		p.policies[i].PathPolicy = policy
+
+		// A later duplicate never matches, the first one wins
+		switch {
+		case policy.Path != "":
+			if _, ok := p.paths[policy.Path]; !ok {
+				p.paths[policy.Path] = i
+			}
+		case policy.Route != "":
+			if _, ok := p.routes[policy.Route]; !ok {
+				p.routes[policy.Route] = i
+			}
+		default:
+			p.prefixes.insert(policy.Prefix, i)
+		}
+	}
+
+	return p, nil
+}
+
+func (n *prefixNode) insert(prefix string, policy int) {
+	for i := 0; i < len(prefix); i++ {
+		child, ok := n.children[prefix[i]]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[byte]*prefixNode)
+			}
+			child = &prefixNode{policy: -1}
+			n.children[prefix[i]] = child
+		}
+		n = child
+	}
+	if n.policy < 0 {
+		n.policy = policy
+	}
+}
+
+// match returns the index of the first policy whose prefix starts path, or -1
+func (n *prefixNode) match(path string) int {
+	first := -1
+	for i := 0; ; i++ {
+		if n.policy >= 0 && (first < 0 || n.policy < first) {
+			first = n.policy
+		}
+		if i == len(path) {
+			return first
+		}
+		child, ok := n.children[path[i]]
+		if !ok {
+			return first
+		}
+		n = child
+	}
+}
+
+// match returns the first policy matching the request, nil when none does
+func (p *pathPolicies) match(c echo.Context) *pathPolicy {
+	first := p.prefixes.match(c.Request().URL.Path)
+	if i, ok := p.paths[c.Request().URL.Path]; ok && (first < 0 || i < first) {
+		first = i
+	}
+	if i, ok := p.routes[c.Path()]; ok && (first < 0 || i < first) {
+		first = i
+	}
+	if first < 0 {
+		return nil
+	}
+	return &p.policies[first]
+}
+
+// drop returns why the entry of a request matching the policy is not logged, or an empty string when it is
+func (p *pathPolicy) drop(status int, id string, now time.Time) string {
+	if status < p.MinStatus {
+		return reasonMinStatus
+	}
+	if p.SampleRate > 0 && !sampleVerbose(id, p.SampleRate) {
+		return reasonSample
+	}
+	if p.RateLimit > 0 && !p.limiter.allow(now, p.RateLimit) {
+		return reasonRateLimit
+	}
+	return ""
+}
+
+// rateLimiter counts the entries over one second windows. The window is reset lock-free, so the limit
+// may be slightly exceeded under contention when a window starts.
+type rateLimiter struct {
+	epoch atomic.Int64
+	count atomic.Int64
+}
+
+func (l *rateLimiter) allow(now time.Time, limit int64) bool {
+	epoch := now.Unix()
+	if old := l.epoch.Load(); old != epoch && l.epoch.CompareAndSwap(old, epoch) {
+		l.count.Store(0)
+	}
+	return l.count.Add(1) <= limit
+}
@@ -0,0 +1,97 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerPathPolicies(t *testing.T) {
+	defer func(f func() float64) { randFloat64 = f }(randFloat64)
+	randFloat64 = func() float64 { return 0.9 }
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{
+		PathPolicies: []PathPolicy{
+			{Path: "/v1/notifications/poll", RateLimit: 2},
+			{Path: "/v1/events/poll", RateLimit: 1},
+			{Prefix: "/v1/", MinStatus: http.StatusBadRequest},
+			// Shadowed by the prefix above
+			{Route: "/v1/users/:id"},
+			{Route: "/static/*", SampleRate: 0.5},
+		},
+	})
+
+	clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	handle.now = func() time.Time { return clock }
+
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/v1/notifications/poll", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	e.GET("/v1/events/poll", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	e.GET("/v1/users/:id", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/v1/fail", func(c echo.Context) error { return c.NoContent(http.StatusBadGateway) })
+	e.GET("/static/*", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/v2/users/:id", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	count := func(path string) int {
+		return logs.FilterField(zap.String("request", "GET "+path)).Len()
+	}
+	serve := func(path string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+	}
+
+	for i := 0; i < 5; i++ {
+		serve("/v1/notifications/poll")
+	}
+	serve("/v1/events/poll")
+	assert.Equal(t, 2, count("/v1/notifications/poll"))
+	assert.Equal(t, 1, count("/v1/events/poll"), "limiters are independent")
+
+	clock = clock.Add(time.Second)
+	serve("/v1/notifications/poll")
+	assert.Equal(t, 3, count("/v1/notifications/poll"))
+
+	serve("/v1/users/1")
+	serve("/v1/fail")
+	serve("/static/app.js")
+	serve("/v2/users/1")
+	assert.Equal(t, 0, count("/v1/users/1"), "the first matching policy wins")
+	assert.Equal(t, 1, count("/v1/fail"))
+	assert.Equal(t, 0, count("/static/app.js"))
+	assert.Equal(t, 1, count("/v2/users/1"), "unmatched requests use the global settings")
+}
+
+func TestNewPathPoliciesInvalid(t *testing.T) {
+	_, err := newPathPolicies([]PathPolicy{{Path: "/a", Prefix: "/a"}})
+	assert.NotNil(t, err)
+
+	_, err = newPathPolicies([]PathPolicy{{RateLimit: 1}})
+	assert.NotNil(t, err)
+
+	_, err = newPathPolicies([]PathPolicy{{Path: "/a", SampleRate: 2}})
+	assert.NotNil(t, err)
+}
+
+func TestPrefixTrieFirstMatch(t *testing.T) {
+	root := &prefixNode{policy: -1}
+	root.insert("/api/v1/", 3)
+	root.insert("/api/", 1)
+	root.insert("/api/v1/", 0)
+	root.insert("/", 2)
+
+	assert.Equal(t, 1, root.match("/api/v1/things"))
+	assert.Equal(t, 2, root.match("/other"))
+	assert.Equal(t, -1, root.match("api"))
+}
@@ -0,0 +1,76 @@
+package echozap
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// openPhase is a phase started by StartPhase that did not end yet
+type openPhase struct {
+	name  string
+	start time.Time
+}
+
+// StartPhase starts timing the named phase of the handler (e.g. "db") and returns the func ending it.
+// The durations and the number of the phases of each name are logged on the access log entry as phase_<name>
+// and phase_<name>_count. Phases still running when the entry is written are ended then, and listed by phase_unclosed.
+// Ending a phase more than once has no effect.
+func StartPhase(c echo.Context, name string) func() {
+	line := canonical(c)
+	now := requestClock(c)
+	p := &openPhase{name: name, start: now()}
+
+	line.mu.Lock()
+	line.phases = append(line.phases, p)
+	line.mu.Unlock()
+
+	return func() {
+		end := now()
+
+		line.mu.Lock()
+		defer line.mu.Unlock()
+		line.endPhase(p, end)
+	}
+}
+
+// endPhase records the duration of p if it is still open. The line must be locked
+func (l *canonicalLine) endPhase(p *openPhase, end time.Time) {
+	for i, open := range l.phases {
+		if open != p {
+			continue
+		}
+		l.phases = append(l.phases[:i], l.phases[i+1:]...)
+
+		l.entry("phase_"+p.name, canonicalTiming).dur += end.Sub(p.start)
+		l.entry("phase_"+p.name+"_count", canonicalCount).count++
+		return
+	}
+}
+
+// closePhases ends the phases still open and returns the phase_unclosed field listing them
+func closePhases(c echo.Context) []zapcore.Field {
+	line, ok := c.Get(canonicalKey).(*canonicalLine)
+	if !ok {
+		return nil
+	}
+
+	end := requestClock(c)()
+
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	if len(line.phases) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(line.phases))
+	for len(line.phases) > 0 {
+		p := line.phases[0]
+		line.endPhase(p, end)
+		names = append(names, p.name)
+	}
+	return []zapcore.Field{zap.Strings("phase_unclosed", names)}
+}
@@ -0,0 +1,98 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerPhases(t *testing.T) {
+	clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var stopRender func()
+	h := func(c echo.Context) error {
+		stopParse := StartPhase(c, "parse")
+		clock = clock.Add(5 * time.Millisecond)
+		stopParse()
+
+		// db phases nested in a handler phase
+		stopHandler := StartPhase(c, "handler")
+		for _, d := range []time.Duration{10, 30, 80} {
+			stopDB := StartPhase(c, "db")
+			clock = clock.Add(d * time.Millisecond)
+			stopDB()
+		}
+		stopHandler()
+		stopHandler()
+
+		// Left open, like the phases of a failing handler
+		stopRender = StartPhase(c, "render")
+		StartPhase(c, "db")
+		clock = clock.Add(7 * time.Millisecond)
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw, handle := ZapLoggerWithHandle(logger, DefaultZapLoggerConfig)
+	defer handle.Close()
+	handle.now = func() time.Time { return clock }
+
+	err := mw(h)(c)
+
+	assert.Nil(t, err)
+
+	// Ending a phase after the entry was written has no effect
+	stopRender()
+
+	entry := logs.AllUntimed()[0]
+	logFields := entry.ContextMap()
+
+	assert.Equal(t, 5*time.Millisecond, logFields["phase_parse"])
+	assert.Equal(t, int64(1), logFields["phase_parse_count"])
+	assert.Equal(t, 120*time.Millisecond, logFields["phase_handler"])
+	assert.Equal(t, int64(1), logFields["phase_handler_count"])
+	assert.Equal(t, 127*time.Millisecond, logFields["phase_db"])
+	assert.Equal(t, int64(4), logFields["phase_db_count"])
+	assert.Equal(t, 7*time.Millisecond, logFields["phase_render"])
+	assert.Equal(t, int64(1), logFields["phase_render_count"])
+	assert.Equal(t, []interface{}{"render", "db"}, logFields["phase_unclosed"])
+
+	// The phases are timed with the clock of the latency
+	assert.Equal(t, "132ms", logFields["latency"])
+}
+
+func TestZapLoggerWithoutUnclosedPhases(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		defer StartPhase(c, "db")()
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	assert.Nil(t, ZapLogger(logger)(h)(c))
+
+	logFields := logs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, int64(1), logFields["phase_db_count"])
+	assert.NotContains(t, logFields, "phase_unclosed")
+}
@@ -0,0 +1,73 @@
+package echozap
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxPriorityRawLength is the maximum number of bytes of an unexpected priority that are logged
+const maxPriorityRawLength = 64
+
+// PriorityConfig defines how the request priority (QoS class) logged as priority is derived
+type PriorityConfig struct {
+	// Request header holding the priority (e.g. X-Request-Priority)
+	Header string
+	// Context key where an earlier middleware stored the priority of the route with c.Set
+	ContextKey string
+	// Whether the context value takes precedence over the header
+	PreferContext bool
+	// Priority logged when none is found. Empty omits the field
+	Default string
+	// Allowed priorities. Other values are logged as "unknown" with the raw value in priority_raw.
+	// When empty, any value is allowed
+	Values []string
+}
+
+func (p PriorityConfig) enabled() bool {
+	return p.Header != "" || p.ContextKey != ""
+}
+
+// fields returns the priority of the request, normalized to lower case
+func (p PriorityConfig) fields(c echo.Context) []zapcore.Field {
+	var fromHeader, fromContext string
+	if p.Header != "" {
+		fromHeader = c.Request().Header.Get(p.Header)
+	}
+	if p.ContextKey != "" {
+		fromContext, _ = c.Get(p.ContextKey).(string)
+	}
+
+	raw := fromHeader
+	if raw == "" || p.PreferContext && fromContext != "" {
+		raw = fromContext
+	}
+	if raw == "" {
+		raw = p.Default
+	}
+
+	priority := strings.ToLower(strings.TrimSpace(raw))
+	if priority == "" {
+		return nil
+	}
+
+	if !p.allowed(priority) {
+		raw, _ = truncate(raw, maxPriorityRawLength)
+		return []zapcore.Field{zap.String("priority", "unknown"), zap.String("priority_raw", raw)}
+	}
+	return []zapcore.Field{zap.String("priority", priority)}
+}
+
+func (p PriorityConfig) allowed(priority string) bool {
+	if len(p.Values) == 0 {
+		return true
+	}
+	for _, v := range p.Values {
+		if strings.EqualFold(v, priority) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerPriority(t *testing.T) {
+	config := PriorityConfig{
+		Header:     "X-Request-Priority",
+		ContextKey: "route_priority",
+		Default:    "normal",
+		Values:     []string{"high", "normal", "batch"},
+	}
+	preferContext := config
+	preferContext.PreferContext = true
+
+	tests := []struct {
+		name     string
+		config   PriorityConfig
+		header   string
+		context  string
+		priority interface{}
+		raw      interface{}
+	}{
+		{name: "header", config: config, header: "HIGH", priority: "high"},
+		{name: "context", config: config, context: "batch", priority: "batch"},
+		{name: "header precedence", config: config, header: "high", context: "batch", priority: "high"},
+		{name: "context precedence", config: preferContext, header: "high", context: "batch", priority: "batch"},
+		{name: "default", config: config, priority: "normal"},
+		{name: "unknown", config: config, header: "urgent!", priority: "unknown", raw: "urgent!"},
+		{name: "no default", config: PriorityConfig{Header: "X-Request-Priority"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-Priority", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.context != "" {
+				c.Set("route_priority", tt.context)
+			}
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{Priority: tt.config})(h)(c)
+
+			assert.Nil(t, err)
+
+			logFields := logs.AllUntimed()[0].ContextMap()
+
+			assert.Equal(t, tt.priority, logFields["priority"])
+			assert.Equal(t, tt.raw, logFields["priority_raw"])
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package echozap
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxRealIPRawLength is the maximum number of bytes of an invalid real IP that are logged
+const maxRealIPRawLength = 64
+
+// validatedRealIP returns c.RealIP() when it is a valid IP address.
+// Otherwise it returns the address of the direct peer along with the fields describing the invalid value,
+// whose IPs are anonymized with mode when anonymizer is set.
+func validatedRealIP(c echo.Context, anonymizer *ipAnonymizer, mode IPAnonMode) (string, []zapcore.Field) {
+	ip := c.RealIP()
+	if net.ParseIP(ip) != nil {
+		return ip, nil
+	}
+
+	if anonymizer != nil {
+		ip = anonymizer.chain(mode, ip)
+	}
+	raw, _ := truncate(ip, maxRealIPRawLength)
+	fields := []zapcore.Field{
+		zap.Bool("real_ip_invalid", true),
+		zap.String("real_ip_raw", raw),
+	}
+
+	peer, _, err := net.SplitHostPort(c.Request().RemoteAddr)
+	if err != nil {
+		peer = c.Request().RemoteAddr
+	}
+	return peer, fields
+}
@@ -0,0 +1,84 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerValidateRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		xff        string
+		remoteAddr string
+		remoteIP   string
+		raw        interface{}
+	}{
+		{
+			name:       "valid",
+			xff:        "203.0.113.7, 10.0.0.1",
+			remoteAddr: "10.0.0.1:4242",
+			remoteIP:   "203.0.113.7",
+		},
+		{
+			name:       "forged multi-value",
+			xff:        "203.0.113.7,198.51.100.1",
+			remoteAddr: "10.0.0.1:4242",
+			remoteIP:   "10.0.0.1",
+			raw:        "203.0.113.7,198.51.100.1",
+		},
+		{
+			name:       "over-long",
+			xff:        strings.Repeat("x", 100),
+			remoteAddr: "10.0.0.1:4242",
+			remoteIP:   "10.0.0.1",
+			raw:        strings.Repeat("x", 64),
+		},
+		{
+			name:     "empty remote address",
+			remoteIP: "",
+			raw:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tt.xff)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{ValidateRealIP: true})(h)(c)
+
+			assert.Nil(t, err)
+
+			logFields := logs.AllUntimed()[0].ContextMap()
+
+			assert.Equal(t, tt.remoteIP, logFields["remote_ip"])
+			assert.Equal(t, tt.raw, logFields["real_ip_raw"])
+			if tt.raw != nil {
+				assert.Equal(t, true, logFields["real_ip_invalid"])
+			} else {
+				assert.NotContains(t, logFields, "real_ip_invalid")
+			}
+		})
+	}
+}
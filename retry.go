@@ -0,0 +1,47 @@
+package echozap
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultRetryAttemptHeaders are the request headers read by IncludeRetryMetadata when RetryAttemptHeaders is empty
+var DefaultRetryAttemptHeaders = []string{"X-Envoy-Attempt-Count", "X-Retry-Attempt"}
+
+// maxAttemptRawLength is the maximum number of bytes of an invalid attempt count that are logged
+const maxAttemptRawLength = 32
+
+// retryFields returns the attempt count of the first header holding a positive integer, flagging retries with is_retry=true.
+// When no header is valid the first invalid value is logged as attempt_raw.
+func retryFields(header http.Header, names []string) []zapcore.Field {
+	raw := ""
+	for _, name := range names {
+		value := strings.TrimSpace(header.Get(name))
+		if value == "" {
+			continue
+		}
+
+		attempt, err := strconv.Atoi(value)
+		if err != nil || attempt < 1 {
+			if raw == "" {
+				raw, _ = truncate(value, maxAttemptRawLength)
+			}
+			continue
+		}
+
+		fields := []zapcore.Field{zap.Int("attempt", attempt)}
+		if attempt > 1 {
+			fields = append(fields, zap.Bool("is_retry", true))
+		}
+		return fields
+	}
+
+	if raw != "" {
+		return []zapcore.Field{zap.String("attempt_raw", raw)}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerRetryMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		names   []string
+		fields  map[string]interface{}
+	}{
+		{
+			name:    "envoy",
+			headers: map[string]string{"X-Envoy-Attempt-Count": "3"},
+			fields:  map[string]interface{}{"attempt": int64(3), "is_retry": true},
+		},
+		{
+			name:    "first attempt",
+			headers: map[string]string{"X-Retry-Attempt": " 1 "},
+			fields:  map[string]interface{}{"attempt": int64(1)},
+		},
+		{
+			name:    "custom",
+			headers: map[string]string{"X-Envoy-Attempt-Count": "5", "Grpc-Previous-Rpc-Attempts": "2"},
+			names:   []string{"Grpc-Previous-Rpc-Attempts"},
+			fields:  map[string]interface{}{"attempt": int64(2), "is_retry": true},
+		},
+		{
+			name:   "missing",
+			fields: map[string]interface{}{},
+		},
+		{
+			name:    "garbage",
+			headers: map[string]string{"X-Envoy-Attempt-Count": "two"},
+			fields:  map[string]interface{}{"attempt_raw": "two"},
+		},
+		{
+			name:    "garbage before a valid value",
+			headers: map[string]string{"X-Envoy-Attempt-Count": "-1", "X-Retry-Attempt": "2"},
+			fields:  map[string]interface{}{"attempt": int64(2), "is_retry": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{IncludeRetryMetadata: true, RetryAttemptHeaders: tt.names})(h)(c)
+
+			assert.Nil(t, err)
+
+			logFields := logs.AllUntimed()[0].ContextMap()
+			for _, k := range []string{"attempt", "is_retry", "attempt_raw"} {
+				assert.Equal(t, tt.fields[k], logFields[k], k)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package echozap
+
+import (
+	"reflect"
+
+	"github.com/labstack/echo/v4"
+)
+
+// unmatchedRoute replaces the route of the requests matching no route in messages
+const unmatchedRoute = "<unmatched>"
+
+var notFoundHandler = reflect.ValueOf(echo.NotFoundHandler).Pointer()
+
+// messageRoute returns the route template matched by the request, or unmatchedRoute.
+// echo sets the path of the unmatched requests to their raw path, which would make the cardinality unbounded.
+func messageRoute(c echo.Context) string {
+	path := c.Path()
+	if path == "" || c.Handler() == nil || reflect.ValueOf(c.Handler()).Pointer() == notFoundHandler {
+		return unmatchedRoute
+	}
+	return path
+}
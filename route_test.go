@@ -0,0 +1,50 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerMessageIncludesRoute(t *testing.T) {
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	e := echo.New()
+	e.Use(ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		MessageIncludesRoute: true,
+		// Ignored in favor of the route
+		IncludeRequestLogMessage: true,
+	}))
+
+	h := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/users/:id", h)
+	e.GET("/orders/:id", h)
+
+	for _, target := range []string{"/users/1", "/users/2?full=true", "/orders/1", "/missing", "/scan/.env"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+	}
+
+	var messages []string
+	for _, entry := range logs.AllUntimed() {
+		messages = append(messages, entry.Message)
+	}
+
+	assert.Equal(t, []string{
+		"Success GET /users/:id",
+		"Success GET /users/:id",
+		"Success GET /orders/:id",
+		"Client error GET <unmatched>",
+		"Client error GET <unmatched>",
+	}, messages)
+}
@@ -0,0 +1,67 @@
+package echozap
+
+import (
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RouteLabels holds the fields logged for the labeled routes of an echo instance, see LabelRoutes
+type RouteLabels struct {
+	fields map[routeKey][]zapcore.Field
+}
+
+type routeKey struct {
+	method, path string
+}
+
+// LabelRoutes returns the labels to log for the routes of e, as ZapLoggerConfig.RouteLabels.
+// labels maps route patterns (e.g. /users/:id) to the fields logged for them, for every method registered on the route.
+// Routes must be registered before calling LabelRoutes, later ones are not labeled.
+func LabelRoutes(e *echo.Echo, labels map[string]map[string]string) *RouteLabels {
+	l := &RouteLabels{fields: make(map[routeKey][]zapcore.Field)}
+
+	for _, r := range e.Routes() {
+		routeLabels, ok := labels[r.Path]
+		if !ok || len(routeLabels) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(routeLabels))
+		for k := range routeLabels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fields := make([]zapcore.Field, len(keys))
+		for i, k := range keys {
+			fields[i] = zap.String(k, routeLabels[k])
+		}
+		l.fields[routeKey{r.Method, r.Path}] = fields
+	}
+
+	return l
+}
+
+// lookup returns the fields of the route matched by the request, nil for unlabeled routes
+func (l *RouteLabels) lookup(c echo.Context) []zapcore.Field {
+	return l.fields[routeKey{c.Request().Method, c.Path()}]
+}
+
+// names returns the sorted names of the labels of all the routes
+func (l *RouteLabels) names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, fields := range l.fields {
+		for _, f := range fields {
+			if !seen[f.Key] {
+				seen[f.Key] = true
+				names = append(names, f.Key)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,60 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerRouteLabels(t *testing.T) {
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	e := echo.New()
+
+	h := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/invoices/:id", h)
+	e.POST("/invoices", h)
+	e.GET("/invoices", h)
+	e.GET("/users/:id", h)
+	e.GET("/status", h)
+
+	// The middleware is registered once the routes are known, echo applies it at request time
+	e.Use(ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		RouteLabels: LabelRoutes(e, map[string]map[string]string{
+			"/invoices/:id": {"domain": "billing", "tier": "gold"},
+			"/invoices":     {"domain": "billing"},
+			"/users/:id":    {"domain": "identity"},
+			"/unregistered": {"domain": "nobody"},
+		}),
+	}))
+
+	for _, r := range []struct{ method, path string }{
+		{http.MethodGet, "/invoices/42"},
+		{http.MethodPost, "/invoices"},
+		{http.MethodGet, "/users/7"},
+		{http.MethodGet, "/status"},
+	} {
+		req := httptest.NewRequest(r.method, r.path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+	}
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, 4, len(entries))
+
+	assert.Equal(t, "billing", entries[0].ContextMap()["domain"])
+	assert.Equal(t, "gold", entries[0].ContextMap()["tier"])
+	assert.Equal(t, "billing", entries[1].ContextMap()["domain"])
+	assert.Equal(t, "identity", entries[2].ContextMap()["domain"])
+	assert.NotContains(t, entries[2].ContextMap(), "tier")
+	assert.NotContains(t, entries[3].ContextMap(), "domain")
+}
@@ -0,0 +1,94 @@
+package echozap
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sampleSlots bounds the state used to remember recent drops, messages are hashed into these slots
+const sampleSlots = 64
+
+// SampleStats counts the sampling decisions of a logger returned by WrapWithSampling
+type SampleStats struct {
+	tick    time.Duration
+	sampled atomic.Uint64
+	dropped atomic.Uint64
+	// last drop time per message slot
+	drops [sampleSlots]atomic.Int64
+}
+
+// Sampled returns the number of entries that were kept by the sampler
+func (s *SampleStats) Sampled() uint64 {
+	return s.sampled.Load()
+}
+
+// Dropped returns the number of entries that were dropped by the sampler
+func (s *SampleStats) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// WrapWithSampling wraps the core of log with a zap sampler (see zapcore.NewSamplerWithOptions) counting its decisions.
+// Entries kept while other entries with the same message were dropped during the same tick get a sampled=true field.
+// Pass the returned stats in ZapLoggerConfig.SampleStats to report them through Handle.Stats.
+func WrapWithSampling(log *zap.Logger, tick time.Duration, first, thereafter int) (*zap.Logger, *SampleStats) {
+	stats := &SampleStats{tick: tick}
+
+	wrapped := log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(
+			&sampledCore{Core: core, stats: stats},
+			tick, first, thereafter,
+			zapcore.SamplerHook(stats.hook),
+		)
+	}))
+
+	return wrapped, stats
+}
+
+func (s *SampleStats) hook(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+	if dec&zapcore.LogDropped > 0 {
+		s.dropped.Add(1)
+		s.drops[sampleSlot(ent.Message)].Store(ent.Time.UnixNano())
+		return
+	}
+	s.sampled.Add(1)
+}
+
+// droppedRecently reports whether an entry with the same message was dropped during the last tick
+func (s *SampleStats) droppedRecently(ent zapcore.Entry) bool {
+	last := s.drops[sampleSlot(ent.Message)].Load()
+	return last != 0 && ent.Time.UnixNano()-last < int64(s.tick)
+}
+
+func sampleSlot(msg string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(msg))
+	return h.Sum32() % sampleSlots
+}
+
+// sampledCore sits below the sampler to annotate the entries that survived a burst
+type sampledCore struct {
+	zapcore.Core
+	stats *SampleStats
+}
+
+func (c *sampledCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sampledCore{Core: c.Core.With(fields), stats: c.stats}
+}
+
+func (c *sampledCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sampledCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.stats.droppedRecently(ent) {
+		fields = append(fields, zap.Bool("sampled", true))
+	}
+	return c.Core.Write(ent, fields)
+}
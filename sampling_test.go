@@ -0,0 +1,49 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerWithSampling(t *testing.T) {
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger, stats := WrapWithSampling(zap.New(obs), time.Minute, 2, 3)
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{
+		SampleStats: stats,
+	})
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	const requests = 10
+	for i := 0; i < requests; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	// Entries 1, 2, 5 and 8 are kept
+	assert.Equal(t, 4, logs.Len())
+	assert.Equal(t, Stats{Sampled: 4, Dropped: 6}, handle.Stats())
+	assert.Equal(t, uint64(requests), stats.Sampled()+stats.Dropped())
+
+	entries := logs.AllUntimed()
+	assert.NotContains(t, entries[0].ContextMap(), "sampled")
+	assert.NotContains(t, entries[1].ContextMap(), "sampled")
+	assert.Equal(t, true, entries[2].ContextMap()["sampled"])
+	assert.Equal(t, true, entries[3].ContextMap()["sampled"])
+}
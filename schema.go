@@ -0,0 +1,225 @@
+package echozap
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Types of FieldDescriptor
+const (
+	FieldTypeString   = "string"
+	FieldTypeInt      = "int"
+	FieldTypeFloat    = "float"
+	FieldTypeBool     = "bool"
+	FieldTypeDuration = "duration"
+	FieldTypeArray    = "array"
+	FieldTypeObject   = "object"
+	FieldTypeAny      = "any"
+)
+
+// FieldDescriptor describes a field of the access log entries
+type FieldDescriptor struct {
+	// Name of the field. Fields nested by FieldGroups are prefixed by their group and a dot (e.g. net.remote_ip),
+	// and a <placeholder> stands for a part of the name chosen by the application (e.g. phase_<name>)
+	Name string `json:"name"`
+	// Type of the value, one of the FieldType constants. Durations are written like 1.5ms, or with the encoder
+	// duration format for the values of Timing and StartPhase
+	Type string `json:"type"`
+	// Whether the field is missing from some entries
+	Optional bool `json:"optional"`
+	// Option or function of the package the field comes from, empty for the built-in fields
+	Option string `json:"option,omitempty"`
+}
+
+// fieldSpec registers a field of the entries
+type fieldSpec struct {
+	name, typ, option string
+	// enabled reports whether the configuration adds the field, nil for the built-in fields selected by the fieldMask
+	enabled func(config ZapLoggerConfig) bool
+}
+
+func always(ZapLoggerConfig) bool { return true }
+
+// verbosePossible reports whether entries can carry the verbose field set
+func verbosePossible(config ZapLoggerConfig) bool {
+	return config.VerboseSampleRate > 0 || (config.ErrorRate.Threshold > 0 && config.ErrorRate.Verbose) ||
+		config.LevelOverrideHeader != "" || config.WatchLogger != nil
+}
+
+// fieldRegistry registers the fields of the entries: the built-in fields indexed by builtinField, which BuildFields and
+// the middleware name theirs after, followed by the fields of the options in the order they are logged.
+// New fields must be registered here, TestDescribeSchemaCoversEntries and TestSchemaJSONCoversEntries fail otherwise.
+var fieldRegistry = []fieldSpec{
+	fieldRemoteIP:  {name: "remote_ip", typ: FieldTypeString},
+	fieldLatency:   {name: "latency", typ: FieldTypeDuration},
+	fieldHost:      {name: "host", typ: FieldTypeString},
+	fieldRequest:   {name: "request", typ: FieldTypeString},
+	fieldStatus:    {name: "status", typ: FieldTypeInt},
+	fieldSize:      {name: "size", typ: FieldTypeInt},
+	fieldUserAgent: {name: "user_agent", typ: FieldTypeString},
+	fieldRequestID: {name: "request_id", typ: FieldTypeString},
+	fieldError:     {name: "error", typ: FieldTypeString},
+
+	{"real_ip_invalid", FieldTypeBool, "ValidateRealIP", func(c ZapLoggerConfig) bool { return c.ValidateRealIP }},
+	{"real_ip_raw", FieldTypeString, "ValidateRealIP", func(c ZapLoggerConfig) bool { return c.ValidateRealIP }},
+	{"status_raw", FieldTypeInt, "NormalizeStatus", func(c ZapLoggerConfig) bool { return c.NormalizeStatus }},
+	{"latency_clamped", FieldTypeBool, "", always},
+	{"latency_suspect", FieldTypeBool, "MaxPlausibleLatency", func(c ZapLoggerConfig) bool { return c.MaxPlausibleLatency > 0 }},
+	{"duplicate_headers", FieldTypeBool, "PreserveMultiValues", func(c ZapLoggerConfig) bool { return c.PreserveMultiValues }},
+	{"level_override_invalid", FieldTypeBool, "LevelOverrideHeader", func(c ZapLoggerConfig) bool { return c.LevelOverrideHeader != "" }},
+	{"synthetic", FieldTypeBool, "TagSynthetic", func(c ZapLoggerConfig) bool { return c.TagSynthetic }},
+	{"error_rate", FieldTypeFloat, "ErrorRate", func(c ZapLoggerConfig) bool { return c.ErrorRate.Threshold > 0 }},
+	{"logical_status", FieldTypeInt, "LogicalStatusFunc", func(c ZapLoggerConfig) bool { return c.LogicalStatusFunc != nil }},
+	{"location", FieldTypeString, "IncludeRedirectLocation", func(c ZapLoggerConfig) bool { return c.IncludeRedirectLocation }},
+	{"response_content_type", FieldTypeString, "IncludeResponseContentType", func(c ZapLoggerConfig) bool { return c.IncludeResponseContentType }},
+	{"format", FieldTypeString, "IncludeResponseContentType", func(c ZapLoggerConfig) bool { return c.IncludeResponseContentType }},
+	{"range", FieldTypeString, "IncludeConditionalRequestInfo", func(c ZapLoggerConfig) bool { return c.IncludeConditionalRequestInfo }},
+	{"partial", FieldTypeBool, "IncludeConditionalRequestInfo", func(c ZapLoggerConfig) bool { return c.IncludeConditionalRequestInfo }},
+	{"not_modified", FieldTypeBool, "IncludeConditionalRequestInfo", func(c ZapLoggerConfig) bool { return c.IncludeConditionalRequestInfo }},
+	{"etag", FieldTypeString, "IncludeConditionalRequestInfo", func(c ZapLoggerConfig) bool { return c.IncludeConditionalRequestInfo }},
+	{"trailers", FieldTypeObject, "LogResponseTrailers", func(c ZapLoggerConfig) bool { return len(c.LogResponseTrailers) > 0 }},
+	{"request_trailers", FieldTypeObject, "LogRequestTrailers", func(c ZapLoggerConfig) bool { return len(c.LogRequestTrailers) > 0 }},
+	{"priority", FieldTypeString, "Priority", func(c ZapLoggerConfig) bool { return c.Priority.enabled() }},
+	{"priority_raw", FieldTypeString, "Priority", func(c ZapLoggerConfig) bool { return c.Priority.enabled() }},
+	{"api_version", FieldTypeString, "APIVersionExtractors", func(c ZapLoggerConfig) bool { return len(c.APIVersionExtractors) > 0 }},
+	{"idempotency_key", FieldTypeString, "IdempotencyKeyHeader", func(c ZapLoggerConfig) bool { return c.IdempotencyKeyHeader != "" }},
+	{"idempotency_key_truncated", FieldTypeBool, "IdempotencyKeyHeader", func(c ZapLoggerConfig) bool { return c.IdempotencyKeyHeader != "" }},
+	{"idempotency_key_invalid", FieldTypeBool, "IdempotencyKeyHeader", func(c ZapLoggerConfig) bool { return c.IdempotencyKeyHeader != "" }},
+	{"locale", FieldTypeString, "IncludeLocale", func(c ZapLoggerConfig) bool { return c.IncludeLocale }},
+	{"locale_raw", FieldTypeString, "IncludeLocale", func(c ZapLoggerConfig) bool { return c.IncludeLocale }},
+	{"attempt", FieldTypeInt, "IncludeRetryMetadata", func(c ZapLoggerConfig) bool { return c.IncludeRetryMetadata }},
+	{"is_retry", FieldTypeBool, "IncludeRetryMetadata", func(c ZapLoggerConfig) bool { return c.IncludeRetryMetadata }},
+	{"attempt_raw", FieldTypeString, "IncludeRetryMetadata", func(c ZapLoggerConfig) bool { return c.IncludeRetryMetadata }},
+	{"client_fingerprint", FieldTypeString, "ClientFingerprint", func(c ZapLoggerConfig) bool { return c.ClientFingerprint != nil }},
+	{"requests_on_connection", FieldTypeInt, "ConnContext", always},
+	{"connection_reused", FieldTypeBool, "ConnContext", always},
+	{"tls_resumed", FieldTypeBool, "ConnContext", always},
+	{"<Count key>", FieldTypeInt, "Count", always},
+	{"<Timing key>", FieldTypeDuration, "Timing", always},
+	{"<Set key>", FieldTypeAny, "Set", always},
+	{"phase_<name>", FieldTypeDuration, "StartPhase", always},
+	{"phase_<name>_count", FieldTypeInt, "StartPhase", always},
+	{"phase_unclosed", FieldTypeArray, "StartPhase", always},
+	{"panicked", FieldTypeBool, "CapturePanics", func(c ZapLoggerConfig) bool { return c.CapturePanics }},
+	{"panic", FieldTypeString, "CapturePanics", func(c ZapLoggerConfig) bool { return c.CapturePanics }},
+	{"stack", FieldTypeString, "CapturePanics", func(c ZapLoggerConfig) bool { return c.CapturePanics }},
+	{"error_fingerprint", FieldTypeString, "ErrorFingerprint", func(c ZapLoggerConfig) bool { return c.ErrorFingerprint }},
+	{"error_type", FieldTypeString, "ErrorFingerprint", func(c ZapLoggerConfig) bool { return c.ErrorFingerprint }},
+	{"validation_errors", FieldTypeArray, "LogBindErrors", func(c ZapLoggerConfig) bool { return c.LogBindErrors }},
+	{"bind_error_offset", FieldTypeInt, "LogBindErrors", func(c ZapLoggerConfig) bool { return c.LogBindErrors }},
+	{"latency_pre_handler", FieldTypeDuration, "IncludeLatencyBreakdown", func(c ZapLoggerConfig) bool { return c.IncludeLatencyBreakdown }},
+	{"latency_handler", FieldTypeDuration, "IncludeLatencyBreakdown", func(c ZapLoggerConfig) bool { return c.IncludeLatencyBreakdown }},
+	{"latency_write", FieldTypeDuration, "IncludeLatencyBreakdown", func(c ZapLoggerConfig) bool { return c.IncludeLatencyBreakdown }},
+	{"write_error", FieldTypeString, "LogWriteErrors", func(c ZapLoggerConfig) bool { return c.LogWriteErrors }},
+	{"bytes_written", FieldTypeInt, "LogWriteErrors", func(c ZapLoggerConfig) bool { return c.LogWriteErrors }},
+	{"bytes_intended", FieldTypeInt, "LogWriteErrors", func(c ZapLoggerConfig) bool { return c.LogWriteErrors }},
+	{"slow_write", FieldTypeBool, "WriteLatencyBudget", func(c ZapLoggerConfig) bool { return c.WriteLatencyBudget > 0 }},
+	{"write_latency", FieldTypeDuration, "WriteLatencyBudget", func(c ZapLoggerConfig) bool { return c.WriteLatencyBudget > 0 }},
+	{"log_decision", FieldTypeString, "ExplainDecisions", func(c ZapLoggerConfig) bool { return c.ExplainDecisions }},
+	{"verbose_sample", FieldTypeBool, "VerboseSampleRate", verbosePossible},
+	{"request_headers", FieldTypeObject, "VerboseFields", func(c ZapLoggerConfig) bool { return verbosePossible(c) && c.VerboseFields.Headers }},
+	{"request_body", FieldTypeString, "VerboseFields", func(c ZapLoggerConfig) bool { return verbosePossible(c) && c.VerboseFields.BodySnippetSize > 0 }},
+	{"tls", FieldTypeObject, "VerboseFields", func(c ZapLoggerConfig) bool { return verbosePossible(c) && c.VerboseFields.TLS }},
+	{"<ExpensiveFieldsFunc field>", FieldTypeAny, "ExpensiveFieldsFunc", func(c ZapLoggerConfig) bool { return c.ExpensiveFieldsFunc != nil }},
+	{"<FieldsFunc field>", FieldTypeAny, "FieldsFunc", func(c ZapLoggerConfig) bool { return c.FieldsFunc != nil }},
+	{"entry_trimmed", FieldTypeBool, "MaxEntryBytes", func(c ZapLoggerConfig) bool { return c.MaxEntryBytes > 0 }},
+	{"trimmed_fields", FieldTypeArray, "MaxEntryBytes", func(c ZapLoggerConfig) bool { return c.MaxEntryBytes > 0 }},
+	{"repeat_base", FieldTypeInt, "CompactRepeats", func(c ZapLoggerConfig) bool { return c.CompactRepeats }},
+	{"repeat_fields", FieldTypeArray, "CompactRepeats", func(c ZapLoggerConfig) bool { return c.CompactRepeats }},
+	{"repeat_of", FieldTypeInt, "CompactRepeats", func(c ZapLoggerConfig) bool { return c.CompactRepeats }},
+	{"watched", FieldTypeBool, "WatchLogger", func(c ZapLoggerConfig) bool { return c.WatchLogger != nil }},
+	{"fallback", FieldTypeBool, "FallbackLogger", func(c ZapLoggerConfig) bool { return c.FallbackLogger != nil }},
+	{"sampled", FieldTypeBool, "WrapWithSampling", func(c ZapLoggerConfig) bool { return c.SampleStats != nil }},
+}
+
+// DescribeSchema returns the fields the access log entries can have with the configuration: the built-in fields kept by
+// OnlyFields and ExcludeFields, nested by FieldGroups, followed by the fields of the options, in the order they are logged.
+// The fields of the context, the route labels and the hooks are listed by name when the configuration knows them.
+func DescribeSchema(config ZapLoggerConfig) []FieldDescriptor {
+	mask, err := newFieldMask(config.OnlyFields, config.ExcludeFields)
+	if err != nil {
+		mask = allFields
+	}
+	groups, _ := newFieldGroups(config.FieldGroups)
+
+	// Fields that only some entries keep
+	dropped := make(map[string]bool)
+	if config.MaxEntryBytes > 0 {
+		for _, name := range trimOrder {
+			dropped[name] = true
+		}
+	}
+	if config.CompactRepeats {
+		compacted := config.CompactFields
+		if len(compacted) == 0 {
+			compacted = DefaultCompactFields
+		}
+		for _, name := range compacted {
+			dropped[name] = true
+		}
+	}
+
+	var top []FieldDescriptor
+	var grouped []FieldDescriptor
+	usedGroups := make(map[int]bool)
+	for i, f := range fieldRegistry[:builtinFieldCount] {
+		if !mask.has(builtinField(i)) {
+			continue
+		}
+		name := f.name
+		d := FieldDescriptor{Name: name, Type: f.typ, Optional: builtinField(i) == fieldError || dropped[name]}
+		if groups == nil {
+			top = append(top, d)
+			continue
+		}
+		g, ok := groups.group[name]
+		if !ok {
+			top = append(top, d)
+			continue
+		}
+		usedGroups[g] = true
+		d.Name = groups.names[g] + "." + name
+		d.Optional = d.Optional || dropped[groups.names[g]]
+		grouped = append(grouped, d)
+	}
+
+	for _, f := range fieldRegistry[builtinFieldCount:] {
+		if f.enabled(config) {
+			top = append(top, FieldDescriptor{Name: f.name, Type: f.typ, Optional: true, Option: f.option})
+		}
+	}
+
+	if config.RouteLabels != nil {
+		for _, name := range config.RouteLabels.names() {
+			top = append(top, FieldDescriptor{Name: name, Type: FieldTypeString, Optional: true, Option: "RouteLabels"})
+		}
+	}
+
+	contextFieldNames := make([]string, 0, len(config.ContextFields))
+	for _, name := range config.ContextFields {
+		contextFieldNames = append(contextFieldNames, name)
+	}
+	sort.Strings(contextFieldNames)
+	for _, name := range contextFieldNames {
+		top = append(top, FieldDescriptor{Name: name, Type: FieldTypeAny, Optional: true, Option: "ContextFields"})
+	}
+
+	// The groups follow the other fields, like in the entries
+	if groups != nil {
+		for i, name := range groups.names {
+			if usedGroups[i] {
+				top = append(top, FieldDescriptor{Name: name, Type: FieldTypeObject, Optional: dropped[name], Option: "FieldGroups"})
+			}
+		}
+	}
+	return append(top, grouped...)
+}
+
+// SchemaJSON returns DescribeSchema as indented JSON, for tools generating parsers.
+// It fails when the configuration is invalid, see ZapLoggerConfig.Validate
+func SchemaJSON(config ZapLoggerConfig) ([]byte, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(DescribeSchema(config), "", "  ")
+}
@@ -0,0 +1,392 @@
+package echozap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDescribeSchemaDefault(t *testing.T) {
+	schema := DescribeSchema(DefaultZapLoggerConfig)
+
+	assert.Equal(t, []FieldDescriptor{
+		{Name: "remote_ip", Type: FieldTypeString},
+		{Name: "latency", Type: FieldTypeDuration},
+		{Name: "host", Type: FieldTypeString},
+		{Name: "request", Type: FieldTypeString},
+		{Name: "status", Type: FieldTypeInt},
+		{Name: "size", Type: FieldTypeInt},
+		{Name: "user_agent", Type: FieldTypeString},
+		{Name: "request_id", Type: FieldTypeString},
+		{Name: "error", Type: FieldTypeString, Optional: true},
+	}, schema[:9])
+
+	for _, d := range schema[9:] {
+		assert.Contains(t, []string{"", "ConnContext", "Count", "Timing", "Set", "StartPhase"}, d.Option, d.Name)
+	}
+}
+
+func TestDescribeSchemaOptions(t *testing.T) {
+	schema := DescribeSchema(ZapLoggerConfig{
+		OnlyFields:    []string{"status", "latency", "user_agent"},
+		FieldGroups:   map[string]string{"status": "http", "latency": "http"},
+		IncludeLocale: true,
+		MaxEntryBytes: 1024,
+		ContextFields: map[string]string{"user": "user_id", "tenant": "tenant_id"},
+	})
+
+	names := make(map[string]FieldDescriptor)
+	for _, d := range schema {
+		names[d.Name] = d
+	}
+
+	assert.Equal(t, FieldDescriptor{Name: "user_agent", Type: FieldTypeString, Optional: true}, names["user_agent"])
+	assert.Equal(t, FieldDescriptor{Name: "http", Type: FieldTypeObject, Option: "FieldGroups"}, names["http"])
+	assert.Equal(t, FieldDescriptor{Name: "http.latency", Type: FieldTypeDuration}, names["http.latency"])
+	assert.Equal(t, FieldDescriptor{Name: "http.status", Type: FieldTypeInt}, names["http.status"])
+	assert.Equal(t, FieldDescriptor{Name: "locale", Type: FieldTypeString, Optional: true, Option: "IncludeLocale"}, names["locale"])
+	assert.Equal(t, FieldDescriptor{Name: "tenant_id", Type: FieldTypeAny, Optional: true, Option: "ContextFields"}, names["tenant_id"])
+	assert.Contains(t, names, "entry_trimmed")
+	assert.NotContains(t, names, "remote_ip")
+	assert.NotContains(t, names, "status")
+	assert.NotContains(t, names, "attempt")
+}
+
+func TestSchemaJSON(t *testing.T) {
+	b, err := SchemaJSON(ZapLoggerConfig{OnlyFields: []string{"status"}})
+	assert.Nil(t, err)
+
+	var schema []FieldDescriptor
+	assert.Nil(t, json.Unmarshal(b, &schema))
+	assert.Equal(t, FieldDescriptor{Name: "status", Type: FieldTypeInt}, schema[0])
+	assert.True(t, bytes.HasPrefix(b, []byte("[\n  {\n    \"name\": \"status\",\n    \"type\": \"int\",\n    \"optional\": false\n  }")), string(b))
+
+	_, err = SchemaJSON(ZapLoggerConfig{OnlyFields: []string{"statuscode"}})
+	assert.NotNil(t, err)
+}
+
+// schemaMatcher finds the descriptor of the fields of an entry
+type schemaMatcher struct {
+	exact    map[string]FieldDescriptor
+	patterns map[*regexp.Regexp]FieldDescriptor
+}
+
+var placeholder = regexp.MustCompile(`<[^>]*>`)
+
+func newSchemaMatcher(schema []FieldDescriptor) *schemaMatcher {
+	m := &schemaMatcher{exact: make(map[string]FieldDescriptor), patterns: make(map[*regexp.Regexp]FieldDescriptor)}
+	for _, d := range schema {
+		if !placeholder.MatchString(d.Name) {
+			m.exact[d.Name] = d
+			continue
+		}
+		// Names made of a placeholder only would match any field
+		if placeholder.ReplaceAllString(d.Name, "") == "" {
+			continue
+		}
+		parts := placeholder.Split(d.Name, -1)
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		m.patterns[regexp.MustCompile("^"+strings.Join(parts, "[a-z0-9_]+")+"$")] = d
+	}
+	return m
+}
+
+// lookup returns the descriptor of the field, the most specific pattern winning (phase_<name>_count over phase_<name>)
+func (m *schemaMatcher) lookup(name string) (FieldDescriptor, bool) {
+	if d, ok := m.exact[name]; ok {
+		return d, true
+	}
+	var found FieldDescriptor
+	for re, d := range m.patterns {
+		if re.MatchString(name) && len(d.Name) > len(found.Name) {
+			found = d
+		}
+	}
+	return found, found.Name != ""
+}
+
+// schemaType returns the schema types a field of the entry can be described with
+func schemaTypes(f zapcore.Field) []string {
+	switch f.Type {
+	case zapcore.StringType, zapcore.ByteStringType, zapcore.StringerType:
+		return []string{FieldTypeString, FieldTypeDuration, FieldTypeAny}
+	case zapcore.DurationType:
+		return []string{FieldTypeDuration, FieldTypeAny}
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Uint64Type, zapcore.Uint32Type:
+		return []string{FieldTypeInt, FieldTypeAny}
+	case zapcore.Float64Type, zapcore.Float32Type:
+		return []string{FieldTypeFloat, FieldTypeAny}
+	case zapcore.BoolType:
+		return []string{FieldTypeBool, FieldTypeAny}
+	case zapcore.ArrayMarshalerType:
+		return []string{FieldTypeArray, FieldTypeAny}
+	case zapcore.ObjectMarshalerType:
+		return []string{FieldTypeObject, FieldTypeAny}
+	case zapcore.ErrorType:
+		return []string{FieldTypeString}
+	default:
+		return []string{FieldTypeAny}
+	}
+}
+
+// assertDescribed checks that the fields of the entries are in the schema with a matching type
+func assertDescribed(t *testing.T, config ZapLoggerConfig, entries []observer.LoggedEntry) map[string]bool {
+	m := newSchemaMatcher(DescribeSchema(config))
+	seen := make(map[string]bool)
+
+	var check func(prefix string, fields []zapcore.Field)
+	check = func(prefix string, fields []zapcore.Field) {
+		for _, f := range fields {
+			if f.Type == zapcore.SkipType {
+				continue
+			}
+			name := prefix + f.Key
+			d, ok := m.lookup(name)
+			if !assert.True(t, ok, "field %s is not in the schema", name) {
+				continue
+			}
+			seen[d.Name] = true
+			assert.Contains(t, schemaTypes(f), d.Type, name)
+
+			if group, ok := f.Interface.(groupMarshaler); ok && d.Option == "FieldGroups" {
+				check(name+".", group)
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		check("", entry.Context)
+	}
+	return seen
+}
+
+// schemaApp returns an application exercising most of the fields, completing the configuration with its routes
+func schemaApp(config *ZapLoggerConfig) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+
+	e.GET("/users/:id", func(c echo.Context) error {
+		c.Set("tenant", "acme")
+		StartPhase(c, "db")()
+		StartPhase(c, "render")
+		return c.JSON(http.StatusOK, map[string]string{"id": c.Param("id")})
+	})
+	e.GET("/v2/old", func(c echo.Context) error {
+		return c.Redirect(http.StatusFound, "/new")
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		return errors.New("database unavailable")
+	})
+	e.GET("/panic", func(c echo.Context) error {
+		panic("nil map")
+	})
+	e.POST("/items", func(c echo.Context) error {
+		var p struct {
+			Count int `json:"count"`
+		}
+		return c.Bind(&p)
+	})
+	e.GET("/grpc", func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	})
+	e.GET("/cached", func(c echo.Context) error {
+		c.Response().Header().Set("ETag", `"v1"`)
+		return c.NoContent(http.StatusNotModified)
+	})
+	e.GET("/partial", func(c echo.Context) error {
+		return c.Blob(http.StatusPartialContent, "application/octet-stream", []byte("abc"))
+	})
+	e.GET("/trailer", func(c echo.Context) error {
+		c.Response().Header().Set("Trailer", "X-Checksum")
+		if err := c.String(http.StatusOK, "body"); err != nil {
+			return err
+		}
+		c.Response().Header().Set("X-Checksum", "abc")
+		return nil
+	})
+
+	config.RouteLabels = LabelRoutes(e, map[string]map[string]string{"/users/:id": {"team": "accounts"}})
+	config.LogicalStatusFunc = func(c echo.Context) (int, bool) {
+		return 13, c.Path() == "/grpc"
+	}
+
+	mw, handle := ZapLoggerWithHandle(zap.NewNop(), *config)
+	handle.Watchlist().Add(WatchRule{PathPrefix: "/users/"})
+
+	e.Use(mw)
+	e.Use(recoverPanics)
+	e.Use(StashPanics(DefaultPanicContextKey))
+	return e
+}
+
+// recoverPanics handles the panics of the next handlers as Server errors, like echo's Recover
+func recoverPanics(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		defer func() {
+			if r := recover(); r != nil {
+				c.Error(fmt.Errorf("%v", r))
+			}
+		}()
+		return next(c)
+	}
+}
+
+// schemaRequests serves requests exercising most of the fields
+func schemaRequests(e *echo.Echo) {
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/users/42", nil),
+		httptest.NewRequest(http.MethodGet, "/v2/old", nil),
+		httptest.NewRequest(http.MethodGet, "/fail", nil),
+		httptest.NewRequest(http.MethodGet, "/panic", nil),
+		httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"count": "many"}`)),
+		httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"count": 1,}`)),
+		httptest.NewRequest(http.MethodGet, "/grpc", nil),
+		httptest.NewRequest(http.MethodGet, "/cached", nil),
+		httptest.NewRequest(http.MethodGet, "/partial", nil),
+		httptest.NewRequest(http.MethodGet, "/trailer", nil),
+		httptest.NewRequest(http.MethodGet, "/missing", nil),
+	}
+
+	for i, req := range requests {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderXRequestID, "req-"+string(rune('a'+i)))
+		req.Header.Add(echo.HeaderXForwardedFor, "not an ip")
+		req.Header.Add(echo.HeaderXForwardedFor, "192.0.2.7")
+		req.Header.Set("Accept-Language", "fr-CH, fr;q=0.9")
+		req.Header.Set("Idempotency-Key", strings.Repeat("k", 200)+" ")
+		req.Header.Set("X-Retry-Attempt", "2")
+		req.Header.Set("X-Envoy-Attempt-Count", "x")
+		req.Header.Set("X-Priority", "urgent")
+		req.Header.Set("X-Log-Level", "loud")
+		req.Header.Set("Range", "bytes=0-2")
+		req.Header.Set("User-Agent", "Pingdom.com_bot")
+		if req.URL.Path == "/fail" {
+			req.Header.Set("User-Agent", strings.Repeat("u", 5000))
+		}
+
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+// schemaConfigs returns configurations enabling most of the options, without and with field groups and compaction
+func schemaConfigs() []ZapLoggerConfig {
+	everything := ZapLoggerConfig{
+		IncludeRequestLogMessage:      true,
+		VerboseSampleRate:             1,
+		VerboseFields:                 VerboseFieldsConfig{Headers: true, BodySnippetSize: 64, TLS: true},
+		LogWriteErrors:                true,
+		IncludeLatencyBreakdown:       true,
+		IncludeRedirectLocation:       true,
+		IdempotencyKeyHeader:          "Idempotency-Key",
+		LogBindErrors:                 true,
+		ContextFields:                 map[string]string{"tenant": "tenant_id"},
+		ValidateRealIP:                true,
+		ErrorFingerprint:              true,
+		ErrorRate:                     ErrorRateConfig{Threshold: 0.01, MinRequests: 1, Verbose: true},
+		LevelFromLogicalStatus:        true,
+		IncludeConditionalRequestInfo: true,
+		IncludeResponseContentType:    true,
+		MaxPlausibleLatency:           time.Nanosecond,
+		ClientFingerprint:             &FingerprintConfig{Secret: []byte("s3cr3t")},
+		WriteLatencyBudget:            time.Nanosecond,
+		APIVersionExtractors:          []func(c echo.Context) (string, bool){VersionFromPathPrefix()},
+		ExplainDecisions:              true,
+		MaxEntryBytes:                 4096,
+		IncludeRetryMetadata:          true,
+		IncludeLocale:                 true,
+		LogResponseTrailers:           []string{"X-Checksum"},
+		Priority:                      PriorityConfig{Header: "X-Priority", Values: []string{"high", "low"}},
+		SyntheticUserAgents:           DefaultSyntheticAgents(),
+		TagSynthetic:                  true,
+		NormalizeStatus:               true,
+		LevelOverrideHeader:           "X-Log-Level",
+		TrustedProxies:                []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		CapturePanics:                 true,
+		PreserveMultiValues:           true,
+	}
+
+	grouped := everything
+	grouped.FieldGroups = map[string]string{"remote_ip": "net", "host": "net", "status": "http", "latency": "http"}
+	grouped.CompactRepeats = true
+	grouped.CompactFields = []string{"net", "user_agent"}
+
+	return []ZapLoggerConfig{everything, grouped, {}}
+}
+
+func TestDescribeSchemaCoversEntries(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, config := range schemaConfigs() {
+		obs, logs := observer.New(zap.DebugLevel)
+		watch, watched := observer.New(zap.DebugLevel)
+		config.WatchLogger = zap.New(watch)
+		config.AccessCore = obs
+
+		schemaRequests(schemaApp(&config))
+
+		assert.True(t, logs.Len() > 0)
+		for name := range assertDescribed(t, config, append(logs.AllUntimed(), watched.AllUntimed()...)) {
+			seen[name] = true
+		}
+	}
+
+	// The scenarios exercise most of the schema, so a field logged under another name than registered is caught
+	for _, name := range []string{"real_ip_raw", "latency_suspect", "synthetic", "logical_status", "location", "format",
+		"partial", "not_modified", "etag", "trailers", "priority_raw", "api_version", "idempotency_key_truncated",
+		"locale", "attempt", "is_retry", "team", "client_fingerprint", "phase_<name>", "phase_unclosed", "tenant_id",
+		"panicked", "stack", "error_type", "validation_errors", "bind_error_offset", "latency_handler", "slow_write",
+		"log_decision", "verbose_sample", "request_headers", "entry_trimmed", "repeat_base", "repeat_of", "http", "net.host",
+		"error", "duplicate_headers", "level_override_invalid", "error_rate", "watched"} {
+		assert.True(t, seen[name], "%s was not logged", name)
+	}
+}
+
+func TestSchemaJSONCoversEntries(t *testing.T) {
+	for _, config := range schemaConfigs() {
+		var out bytes.Buffer
+		config.AccessCore = zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"}), zapcore.AddSync(&out), zap.DebugLevel)
+		config.WatchLogger = zap.New(config.AccessCore)
+		schemaRequests(schemaApp(&config))
+
+		b, err := SchemaJSON(config)
+		assert.Nil(t, err)
+		var schema []FieldDescriptor
+		assert.Nil(t, json.Unmarshal(b, &schema))
+		m := newSchemaMatcher(schema)
+
+		var check func(prefix string, entry map[string]interface{})
+		check = func(prefix string, entry map[string]interface{}) {
+			for k, v := range entry {
+				d, ok := m.lookup(prefix + k)
+				if !assert.True(t, ok, "field %s%s is not in the schema", prefix, k) {
+					continue
+				}
+				if group, ok := v.(map[string]interface{}); ok && d.Option == "FieldGroups" {
+					check(prefix+k+".", group)
+				}
+			}
+		}
+
+		entries := decodeLines(t, &out)
+		assert.True(t, len(entries) > 0)
+		for _, entry := range entries {
+			delete(entry, "msg")
+			check("", entry)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package echozap
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// shutdownSyncTimeout bounds the time spent syncing the logger on shutdown, since a network sink may hang
+var shutdownSyncTimeout = 5 * time.Second
+
+// Shutdown gracefully shuts e down like e.Shutdown, waiting for the requests in flight, then closes the handles,
+// flushing their pending summaries, and syncs log. The flush happens once the server stopped, so the last entries and
+// the summaries include every request. It also happens when the shutdown fails or ctx expires, whose error is returned.
+// It is safe to use without any buffering feature enabled.
+func Shutdown(ctx context.Context, e *echo.Echo, log *zap.Logger, handles ...*Handle) error {
+	err := e.Shutdown(ctx)
+	newShutdownFlusher(log, handles).flush()
+	return err
+}
+
+// FlushOnSignal calls Shutdown when the process receives SIGTERM or SIGINT, or when ctx is done, giving the requests
+// in flight up to grace to complete. The returned channel receives the error of Shutdown once the logger is synced,
+// and is then closed: the process should wait for it before exiting. The stop function releases the signal handler
+// and shuts the server down as well.
+//
+//	done, stop := echozap.FlushOnSignal(context.Background(), e, 10*time.Second, logger, handle)
+//	defer stop()
+//	if err := e.Start(":1323"); err != http.ErrServerClosed {
+//		logger.Fatal("server failed", zap.Error(err))
+//	}
+//	<-done
+func FlushOnSignal(ctx context.Context, e *echo.Echo, grace time.Duration, log *zap.Logger, handles ...*Handle) (<-chan error, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		done <- Shutdown(shutdownCtx, e, log, handles...)
+	}()
+	return done, stop
+}
+
+type shutdownFlusher struct {
+	log     *zap.Logger
+	handles []*Handle
+	once    sync.Once
+}
+
+func newShutdownFlusher(log *zap.Logger, handles []*Handle) *shutdownFlusher {
+	return &shutdownFlusher{log: log, handles: handles}
+}
+
+func (f *shutdownFlusher) flush() {
+	f.once.Do(func() {
+		for _, h := range f.handles {
+			if h != nil {
+				_ = h.Close()
+			}
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// Syncing stderr fails on some platforms, there is nobody to report the error to anyway
+			_ = f.log.Sync()
+		}()
+
+		t := time.NewTimer(shutdownSyncTimeout)
+		defer t.Stop()
+		select {
+		case <-done:
+		case <-t.C:
+		}
+	})
+}
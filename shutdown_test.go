@@ -0,0 +1,125 @@
+package echozap
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// syncCounter is a discarding WriteSyncer counting the calls to Sync
+type syncCounter struct {
+	syncs atomic.Int32
+}
+
+func (s *syncCounter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (s *syncCounter) Sync() error {
+	s.syncs.Add(1)
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	obs, logs := observer.New(zap.DebugLevel)
+
+	syncer := &syncCounter{}
+	logger := zap.New(zapcore.NewTee(obs, zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), syncer, zap.DebugLevel)))
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{
+		SummaryInterval:  time.Hour,
+		HealthCheckPaths: []string{"/healthz"},
+	})
+
+	started, release := make(chan struct{}), make(chan struct{})
+
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.Use(mw)
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/slow", func(c echo.Context) error {
+		close(started)
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	e.Listener = l
+	go func() { _ = e.Start("") }()
+
+	url := "http://" + l.Addr().String()
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(url + "/healthz")
+		assert.Nil(t, err)
+		_ = res.Body.Close()
+	}
+
+	slow := make(chan error, 1)
+	go func() {
+		res, err := http.Get(url + "/slow")
+		if err == nil {
+			_ = res.Body.Close()
+		}
+		slow <- err
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- Shutdown(context.Background(), e, logger, handle) }()
+
+	// The request in flight completes before the flush
+	close(release)
+	assert.Nil(t, <-slow)
+	assert.Nil(t, <-shutdown)
+
+	assert.Equal(t, int32(1), syncer.syncs.Load())
+	assert.Equal(t, 1, logs.FilterField(zap.String("request", "GET /slow")).Len())
+	assert.Equal(t, int64(2), logs.FilterField(zap.String("path", "/healthz")).AllUntimed()[0].ContextMap()["hits"])
+	assert.Equal(t, int64(3), logs.FilterFieldKey("p50").AllUntimed()[0].ContextMap()["count"])
+}
+
+func TestFlushOnShutdownWithoutBuffering(t *testing.T) {
+	syncer := &syncCounter{}
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), syncer, zap.DebugLevel))
+
+	_, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{})
+
+	f := newShutdownFlusher(logger, []*Handle{handle, nil})
+	f.flush()
+	f.flush()
+
+	assert.Equal(t, int32(1), syncer.syncs.Load())
+}
+
+func TestFlushOnSignal(t *testing.T) {
+	syncer := &syncCounter{}
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), syncer, zap.DebugLevel))
+
+	_, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{})
+
+	parent, cancel := context.WithCancel(context.Background())
+	done, stop := FlushOnSignal(parent, echo.New(), time.Second, logger, handle)
+	defer stop()
+
+	cancel()
+
+	assert.Nil(t, <-done)
+	assert.Equal(t, int32(1), syncer.syncs.Load())
+
+	// The channel is closed once the error was received
+	_, ok := <-done
+	assert.False(t, ok)
+}
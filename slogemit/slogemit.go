@@ -0,0 +1,202 @@
+// Package slogemit writes the echozap access log entries to a log/slog logger.
+//
+// The middleware measures and classifies the requests exactly like the zap one: only the writing of the entries differs.
+// The fields are converted to attributes by type: namespaces, objects and field groups become groups, and durations,
+// including the latencies zap logs as strings, become slog.Duration values.
+package slogemit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/Unity-Technologies/echozap"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Config is the configuration of the middleware. The fields defining the zap logger of the entries, AccessCore, Options,
+// DisableCaller, EmitFunc and Sink, are ignored. The FallbackLogger receives the entries the handler failed to write
+type Config = echozap.ZapLoggerConfig
+
+// New returns a middleware writing the access log entries to logger.
+// Like echozap.ZapLoggerWithConfig, it panics when the configuration starts background work: use NewWithHandle instead.
+func New(logger *slog.Logger, cfg Config) echo.MiddlewareFunc {
+	return echozap.ZapLoggerWithConfig(configure(logger, cfg))
+}
+
+// NewWithHandle is like New but also returns a Handle controlling the middleware background work.
+// The handle should be closed on shutdown so pending summaries are flushed.
+func NewWithHandle(logger *slog.Logger, cfg Config) (echo.MiddlewareFunc, *echozap.Handle) {
+	return echozap.ZapLoggerWithHandle(configure(logger, cfg))
+}
+
+// configure returns the zap logger and the configuration of a middleware writing to logger
+func configure(logger *slog.Logger, cfg Config) (*zap.Logger, Config) {
+	cfg.AccessCore = nil
+	cfg.Options = nil
+	cfg.DisableCaller = false
+	cfg.EmitFunc = nil
+	cfg.Sink = &sink{handler: logger.Handler()}
+
+	// The summaries and the reports of the middleware are written to the same handler
+	return zap.New(&core{handler: logger.Handler()}), cfg
+}
+
+// sink writes the entries with the request context, so handlers can correlate them with the active trace
+type sink struct {
+	handler slog.Handler
+}
+
+func (s *sink) Enabled(c echo.Context, level zapcore.Level) bool {
+	return s.handler.Enabled(c.Request().Context(), slogLevel(level))
+}
+
+func (s *sink) Emit(c echo.Context, v echozap.Values) error {
+	r := slog.NewRecord(v.Start.Add(v.Latency), slogLevel(v.Level), v.Message, 0)
+	r.AddAttrs(fieldAttrs(v.Fields)...)
+	return s.handler.Handle(c.Request().Context(), r)
+}
+
+// slogLevel returns the slog level of a zap level. Debug, Info, Warn and Error are mapped to their slog equivalent,
+// the levels above Error keep their distance to it
+func slogLevel(l zapcore.Level) slog.Level {
+	return slog.Level(l) * 4
+}
+
+// fieldObject is implemented by the objects holding fields, such as the groups of ZapLoggerConfig.FieldGroups
+type fieldObject interface {
+	Fields() []zapcore.Field
+}
+
+// fieldAttrs returns the fields as attributes, in order. The fields following a namespace are nested in its group
+func fieldAttrs(fields []zapcore.Field) []slog.Attr {
+	as := make([]slog.Attr, 0, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.SkipType:
+		case zapcore.NamespaceType:
+			return append(as, slog.Attr{Key: f.Key, Value: slog.GroupValue(fieldAttrs(fields[i+1:])...)})
+		default:
+			as = append(as, fieldAttr(f)...)
+		}
+	}
+	return as
+}
+
+// fieldAttr converts a field by type. Inline objects may add several attributes
+func fieldAttr(f zapcore.Field) []slog.Attr {
+	switch f.Type {
+	case zapcore.DurationType:
+		return []slog.Attr{slog.Duration(f.Key, time.Duration(f.Integer))}
+	case zapcore.StringerType:
+		if d, ok := f.Interface.(time.Duration); ok {
+			return []slog.Attr{slog.Duration(f.Key, d)}
+		}
+	case zapcore.ObjectMarshalerType:
+		if o, ok := f.Interface.(fieldObject); ok {
+			return []slog.Attr{{Key: f.Key, Value: slog.GroupValue(fieldAttrs(o.Fields())...)}}
+		}
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return attrs(enc.Fields)
+}
+
+func attrs(m map[string]interface{}) []slog.Attr {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	as := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		as = append(as, slog.Attr{Key: k, Value: value(m[k])})
+	}
+	return as
+}
+
+func value(v interface{}) slog.Value {
+	switch v := v.(type) {
+	case string:
+		return slog.StringValue(v)
+	case bool:
+		return slog.BoolValue(v)
+	case int:
+		return slog.IntValue(v)
+	case int64:
+		return slog.Int64Value(v)
+	case int32:
+		return slog.Int64Value(int64(v))
+	case int16:
+		return slog.Int64Value(int64(v))
+	case int8:
+		return slog.Int64Value(int64(v))
+	case uint64:
+		return slog.Uint64Value(v)
+	case uint32:
+		return slog.Uint64Value(uint64(v))
+	case uint16:
+		return slog.Uint64Value(uint64(v))
+	case uint8:
+		return slog.Uint64Value(uint64(v))
+	case float64:
+		return slog.Float64Value(v)
+	case float32:
+		return slog.Float64Value(float64(v))
+	case time.Duration:
+		return slog.DurationValue(v)
+	case time.Time:
+		return slog.TimeValue(v)
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			values = append(values, value(e).Any())
+		}
+		return slog.AnyValue(values)
+	case map[string]interface{}:
+		return slog.GroupValue(attrs(v)...)
+	case error:
+		return slog.StringValue(v.Error())
+	case fmt.Stringer:
+		return slog.StringValue(v.String())
+	default:
+		return slog.AnyValue(v)
+	}
+}
+
+// core is a zapcore.Core writing to a slog handler, for the records of the middleware other than the entries
+type core struct {
+	handler slog.Handler
+	fields  []zapcore.Field
+}
+
+func (c *core) Enabled(l zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), slogLevel(l))
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{handler: c.handler, fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+}
+
+func (c *core) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c *core) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	r := slog.NewRecord(e.Time, slogLevel(e.Level), e.Message, 0)
+	r.AddAttrs(fieldAttrs(append(c.fields[:len(c.fields):len(c.fields)], fields...))...)
+	return c.handler.Handle(context.Background(), r)
+}
+
+func (c *core) Sync() error {
+	return nil
+}
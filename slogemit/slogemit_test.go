@@ -0,0 +1,238 @@
+package slogemit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Unity-Technologies/echozap"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recorder is a slog.Handler keeping the records
+type recorder struct {
+	level   slog.Level
+	mu      sync.Mutex
+	records []slog.Record
+	ctxs    []context.Context
+}
+
+func (r *recorder) Enabled(_ context.Context, l slog.Level) bool { return l >= r.level }
+func (r *recorder) WithAttrs([]slog.Attr) slog.Handler           { return r }
+func (r *recorder) WithGroup(string) slog.Handler                { return r }
+
+func (r *recorder) Handle(ctx context.Context, rec slog.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+	r.ctxs = append(r.ctxs, ctx)
+	return nil
+}
+
+// attrMap returns the attributes of the record, with the groups as nested maps
+func attrMap(as []slog.Attr) map[string]interface{} {
+	m := make(map[string]interface{}, len(as))
+	for _, a := range as {
+		if a.Value.Kind() == slog.KindGroup {
+			m[a.Key] = attrMap(a.Value.Group())
+			continue
+		}
+		m[a.Key] = a.Value.Any()
+	}
+	return m
+}
+
+func recordAttrs(r slog.Record) []slog.Attr {
+	var as []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		as = append(as, a)
+		return true
+	})
+	return as
+}
+
+type ctxKey struct{}
+
+func TestParity(t *testing.T) {
+	config := Config{
+		IncludeRequestLogMessage: true,
+		IdempotencyKeyHeader:     "Idempotency-Key",
+		FieldsFunc: func(c echo.Context, v echozap.Values) []zapcore.Field {
+			return []zapcore.Field{zap.Namespace("app"), zap.String("user", "u-1"), zap.Int("items", 3)}
+		},
+	}
+
+	serve := func(mw echo.MiddlewareFunc) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/orders?page=2", nil)
+		req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, "trace"))
+		req.Header.Set(echo.HeaderXRequestID, "req-1")
+		req.Header.Set("Idempotency-Key", "key-1")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			echozap.Timing(c, "db", 25*time.Millisecond)
+			echozap.Count(c, "queries", 2)
+			return c.String(http.StatusConflict, "")
+		}
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+	serve(echozap.ZapLoggerWithConfig(zap.New(obs), config))
+
+	handler := &recorder{level: slog.LevelDebug}
+	serve(New(slog.New(handler), config))
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, 1, len(handler.records))
+
+	zapEntry := logs.AllUntimed()[0]
+	slogRecord := handler.records[0]
+
+	assert.Equal(t, "Client error: POST /orders?page=2", slogRecord.Message)
+	assert.Equal(t, zapEntry.Message, slogRecord.Message)
+	assert.Equal(t, slog.LevelWarn, slogRecord.Level)
+	assert.Equal(t, "trace", handler.ctxs[0].Value(ctxKey{}))
+
+	zapFields := zapEntry.ContextMap()
+	slogFields := attrMap(recordAttrs(slogRecord))
+
+	// The latency is a string for zap and a duration for slog
+	zapLatency, err := time.ParseDuration(zapFields["latency"].(string))
+	assert.Nil(t, err)
+	assert.True(t, zapLatency > 0)
+	assert.IsType(t, time.Duration(0), slogFields["latency"])
+	delete(zapFields, "latency")
+	delete(slogFields, "latency")
+
+	assert.Equal(t, zapFields, slogFields)
+	assert.Equal(t, map[string]interface{}{"user": "u-1", "items": int64(3)}, slogFields["app"])
+	assert.Equal(t, 25*time.Millisecond, slogFields["db"])
+}
+
+func TestFieldGroups(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	handler := &recorder{level: slog.LevelDebug}
+	config := Config{
+		FieldGroups: map[string]string{"status": "http", "latency": "http"},
+		FieldsFunc: func(c echo.Context, v echozap.Values) []zapcore.Field {
+			return []zapcore.Field{zap.Duration("timeout", time.Second)}
+		},
+	}
+	assert.Nil(t, New(slog.New(handler), config)(h)(c))
+
+	assert.Equal(t, 1, len(handler.records))
+	fields := attrMap(recordAttrs(handler.records[0]))
+
+	// The durations are converted by type, wherever they are nested
+	group, ok := fields["http"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, int64(http.StatusOK), group["status"])
+	assert.IsType(t, time.Duration(0), group["latency"])
+	assert.NotContains(t, fields, "latency")
+	assert.Equal(t, time.Second, fields["timeout"])
+}
+
+// failingHandler is a slog.Handler failing to write the entries
+type failingHandler struct {
+	recorder
+}
+
+func (h *failingHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if rec.Message == "echozap: emitter failed" {
+		return h.recorder.Handle(ctx, rec)
+	}
+	return errors.New("unavailable")
+}
+
+func TestFallbackLogger(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	handler := &failingHandler{recorder: recorder{level: slog.LevelDebug}}
+	obs, logs := observer.New(zap.DebugLevel)
+
+	mw, handle := NewWithHandle(slog.New(handler), Config{FallbackLogger: zap.New(obs)})
+	assert.Nil(t, mw(h)(c))
+	assert.Nil(t, handle.Close())
+
+	assert.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "Success", entry.Message)
+	assert.Equal(t, true, entry.ContextMap()["fallback"])
+	assert.Equal(t, int64(http.StatusOK), entry.ContextMap()["status"])
+	assert.Equal(t, uint64(1), handle.Stats().Fallbacks)
+
+	// The failure is still reported
+	assert.Equal(t, 1, len(handler.records))
+	assert.Equal(t, "unavailable", attrMap(recordAttrs(handler.records[0]))["error"])
+}
+
+func TestLevelGating(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	handler := &recorder{level: slog.LevelWarn}
+
+	var calls int
+	mw, handle := NewWithHandle(slog.New(handler), Config{
+		SummaryInterval: time.Hour,
+		ExpensiveFieldsFunc: func(echo.Context) []zapcore.Field {
+			calls++
+			return nil
+		},
+	})
+
+	assert.Nil(t, mw(h)(c))
+
+	assert.Equal(t, 0, len(handler.records))
+	assert.Equal(t, 0, calls)
+
+	// The summaries are written to the same handler
+	handler.level = slog.LevelDebug
+	assert.Nil(t, handle.Close())
+
+	assert.Equal(t, 1, len(handler.records))
+	assert.Contains(t, handler.records[0].Message, "count=1")
+	assert.Equal(t, int64(1), attrMap(recordAttrs(handler.records[0]))["count"])
+}
+
+func TestSlogLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, slogLevel(zapcore.DebugLevel))
+	assert.Equal(t, slog.LevelInfo, slogLevel(zapcore.InfoLevel))
+	assert.Equal(t, slog.LevelWarn, slogLevel(zapcore.WarnLevel))
+	assert.Equal(t, slog.LevelError, slogLevel(zapcore.ErrorLevel))
+	assert.True(t, slogLevel(zapcore.FatalLevel) > slog.LevelError)
+}
@@ -0,0 +1,37 @@
+package echozap
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// isInterim reports whether the status is an informational response followed by the final one.
+// 101 Switching Protocols is final, the connection is handed over
+func isInterim(code int) bool {
+	return code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+}
+
+// normalizeStatus returns the effective status of a response for which echo reports status.
+// It is unchanged unless it is 0 or interim.
+func normalizeStatus(status int, w *trackingWriter, err error) int {
+	if status != 0 && !isInterim(status) {
+		return status
+	}
+
+	switch {
+	case w.status != 0:
+		return w.status
+	case w.written > 0:
+		// net/http sends an implicit 200 on the first write
+		return http.StatusOK
+	case err != nil:
+		if he, ok := err.(*echo.HTTPError); ok {
+			return he.Code
+		}
+		return http.StatusInternalServerError
+	default:
+		// net/http sends an implicit 200 when the handler returns
+		return http.StatusOK
+	}
+}
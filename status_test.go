@@ -0,0 +1,111 @@
+package echozap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerNormalizeStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      echo.HandlerFunc
+		errorHandler echo.HTTPErrorHandler
+		status       int64
+		raw          interface{}
+	}{
+		{
+			name: "early hints on the writer",
+			handler: func(c echo.Context) error {
+				c.Response().Writer.WriteHeader(http.StatusEarlyHints)
+				return c.NoContent(http.StatusAccepted)
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name: "nothing written",
+			handler: func(c echo.Context) error {
+				return nil
+			},
+			status: http.StatusOK,
+			raw:    int64(0),
+		},
+		{
+			name: "error not written",
+			handler: func(c echo.Context) error {
+				return echo.ErrForbidden
+			},
+			errorHandler: func(error, echo.Context) {},
+			status:       http.StatusForbidden,
+			raw:          int64(0),
+		},
+		{
+			name: "switching protocols",
+			handler: func(c echo.Context) error {
+				return c.NoContent(http.StatusSwitchingProtocols)
+			},
+			status: http.StatusSwitchingProtocols,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			if tt.errorHandler != nil {
+				e.HTTPErrorHandler = tt.errorHandler
+			}
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{NormalizeStatus: true})(tt.handler)(c)
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, 1, logs.Len())
+			fields := logs.AllUntimed()[0].ContextMap()
+			assert.Equal(t, tt.status, fields["status"])
+			assert.Equal(t, tt.raw, fields["status_raw"])
+		})
+	}
+}
+
+func TestZapLoggerNormalizeStatusEarlyHints(t *testing.T) {
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	e := echo.New()
+	e.Use(ZapLoggerWithConfig(logger, ZapLoggerConfig{NormalizeStatus: true}))
+	e.GET("/something", func(c echo.Context) error {
+		c.Response().Header().Add("Link", "</style.css>; rel=preload; as=style")
+		c.Response().WriteHeader(http.StatusEarlyHints)
+		// echo ignores the final WriteHeader, net/http sends an implicit 200 on the first write
+		return c.String(http.StatusOK, "hinted")
+	})
+
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/something")
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hinted", string(body))
+
+	assert.Equal(t, 1, logs.Len())
+	fields := logs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, int64(http.StatusOK), fields["status"])
+	assert.Equal(t, int64(http.StatusEarlyHints), fields["status_raw"])
+}
@@ -0,0 +1,117 @@
+package echozap
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// latencyBuckets is the number of buckets of the latency histogram, enough for about 12 days in microseconds
+const latencyBuckets = 160
+
+// latencySummary is a lock-free log-linear histogram of the request latencies.
+// Each power of two is split in 4 buckets, so percentiles are reported within 25%.
+type latencySummary struct {
+	log *zap.Logger
+	// since is the start of the current period, only accessed by flush
+	since time.Time
+
+	buckets [latencyBuckets]atomic.Int64
+	errors  atomic.Int64
+	max     atomic.Int64
+}
+
+func newLatencySummary(log *zap.Logger, since time.Time) *latencySummary {
+	return &latencySummary{log: log, since: since}
+}
+
+func (s *latencySummary) record(latency time.Duration, status int) {
+	s.buckets[latencyBucket(latency)].Add(1)
+	if status >= http.StatusInternalServerError {
+		s.errors.Add(1)
+	}
+	for {
+		max := s.max.Load()
+		if int64(latency) <= max || s.max.CompareAndSwap(max, int64(latency)) {
+			break
+		}
+	}
+}
+
+// latencyBucket returns the bucket of a latency: values below 4µs have their own bucket,
+// larger ones are indexed by their exponent and the 2 bits following their leading bit.
+func latencyBucket(d time.Duration) int {
+	v := uint64(d / time.Microsecond)
+	if d < 0 {
+		v = 0
+	}
+	if v < 4 {
+		return int(v)
+	}
+
+	e := bits.Len64(v) - 1
+	i := (e-2)*4 + int(v>>uint(e-2))
+	if i >= latencyBuckets {
+		return latencyBuckets - 1
+	}
+	return i
+}
+
+// latencyBucketBound returns the upper bound of a bucket
+func latencyBucketBound(i int) time.Duration {
+	if i < 4 {
+		return time.Duration(i) * time.Microsecond
+	}
+	e, m := i/4+1, uint64(i%4+4)
+	return time.Duration((m+1)<<uint(e-2)-1) * time.Microsecond
+}
+
+// flush emits the summary of the requests recorded since the previous flush, over the period ending at now
+func (s *latencySummary) flush(now time.Time) {
+	period := now.Sub(s.since).Round(time.Millisecond)
+	s.since = now
+
+	var counts [latencyBuckets]int64
+	var count int64
+	for i := range s.buckets {
+		counts[i] = s.buckets[i].Swap(0)
+		count += counts[i]
+	}
+	errors := s.errors.Swap(0)
+	max := time.Duration(s.max.Swap(0))
+
+	if count == 0 {
+		return
+	}
+
+	percentile := func(p float64) time.Duration {
+		rank := int64(math.Ceil(p * float64(count)))
+		var seen int64
+		for i, n := range counts {
+			seen += n
+			if seen >= rank {
+				if b := latencyBucketBound(i); b < max {
+					return b
+				}
+				return max
+			}
+		}
+		return max
+	}
+	p50, p95, p99 := percentile(0.50), percentile(0.95), percentile(0.99)
+
+	s.log.Info(
+		fmt.Sprintf("last %s: count=%d p50=%s p95=%s p99=%s max=%s errors=%d", period, count, p50, p95, p99, max, errors),
+		zap.Int64("count", count),
+		zap.String("p50", p50.String()),
+		zap.String("p95", p95.String()),
+		zap.String("p99", p99.String()),
+		zap.String("max", max.String()),
+		zap.Int64("errors", errors),
+	)
+}
@@ -0,0 +1,107 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLatencyBucket(t *testing.T) {
+	for _, us := range []int64{0, 1, 3, 4, 7, 8, 9, 10, 999, 1000, 1001, 123456, 3e9} {
+		d := time.Duration(us) * time.Microsecond
+		i := latencyBucket(d)
+
+		assert.True(t, d <= latencyBucketBound(i), us)
+		if i > 0 {
+			assert.True(t, d > latencyBucketBound(i-1), us)
+		}
+		assert.True(t, float64(latencyBucketBound(i)) <= float64(d)*1.25, us)
+	}
+}
+
+func TestZapLoggerSummary(t *testing.T) {
+	defer func(f func(time.Duration) (<-chan time.Time, func())) { newTicker = f }(newTicker)
+
+	tick := make(chan time.Time)
+	newTicker = func(time.Duration) (<-chan time.Time, func()) {
+		return tick, func() {}
+	}
+
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	// summaries receives the summary entries once written, so the test waits for the ticker goroutine
+	summaries := make(chan struct{}, 1)
+	logger := zap.New(obs, zap.Hooks(func(e zapcore.Entry) error {
+		if strings.HasPrefix(e.Message, "last ") {
+			summaries <- struct{}{}
+		}
+		return nil
+	}))
+
+	mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{SummaryInterval: time.Minute})
+
+	clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	handle.now = func() time.Time { return clock }
+	handle.summary.since = clock
+
+	serve := func(latency time.Duration, status int) {
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			clock = clock.Add(latency)
+			return c.NoContent(status)
+		}
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	// 1ms to 100ms, every tenth request failing
+	for i := 1; i <= 100; i++ {
+		status := http.StatusOK
+		if i%10 == 0 {
+			status = http.StatusInternalServerError
+		}
+		serve(time.Duration(i)*time.Millisecond, status)
+	}
+
+	tick <- clock
+	<-summaries
+
+	assert.Equal(t, 101, logs.Len())
+
+	summary := logs.AllUntimed()[100]
+	logFields := summary.ContextMap()
+
+	// The period is measured by the clock of the handle, 1ms to 100ms elapsed
+	assert.Contains(t, summary.Message, "last 5.05s: count=100 p50=")
+	assert.Contains(t, summary.Message, "max=100ms errors=10")
+	assert.Equal(t, int64(100), logFields["count"])
+	assert.Equal(t, int64(10), logFields["errors"])
+	assert.Equal(t, "100ms", logFields["max"])
+
+	for key, want := range map[string]time.Duration{"p50": 50 * time.Millisecond, "p95": 95 * time.Millisecond, "p99": 99 * time.Millisecond} {
+		got, err := time.ParseDuration(logFields[key].(string))
+		assert.Nil(t, err)
+		assert.True(t, got >= want && float64(got) <= float64(want)*1.25, "%s=%s", key, got)
+	}
+
+	serve(2*time.Second, http.StatusOK)
+
+	assert.Nil(t, handle.Close())
+	<-summaries
+
+	assert.Equal(t, 103, logs.Len())
+	assert.Equal(t, "last 2s: count=1 p50=2s p95=2s p99=2s max=2s errors=0", logs.AllUntimed()[102].Message)
+}
@@ -0,0 +1,46 @@
+package echozap
+
+import "strings"
+
+// DefaultSyntheticAgents returns User-Agent patterns of common monitoring and health check clients
+func DefaultSyntheticAgents() []string {
+	return []string{
+		"kube-probe",
+		"ELB-HealthChecker",
+		"GoogleHC",
+		"Pingdom",
+		"UptimeRobot",
+		"StatusCake",
+		"Site24x7",
+		"Datadog/Synthetics",
+		"NewRelicSynthetics",
+		"Amazon-Route53-Health-Check-Service",
+		"Consul Health Check",
+	}
+}
+
+// syntheticMatcher matches User-Agents containing one of the patterns, case-insensitively
+type syntheticMatcher []string
+
+func newSyntheticMatcher(patterns []string) syntheticMatcher {
+	m := make(syntheticMatcher, 0, len(patterns))
+	for _, p := range patterns {
+		if p != "" {
+			m = append(m, strings.ToLower(p))
+		}
+	}
+	return m
+}
+
+func (m syntheticMatcher) matches(userAgent string) bool {
+	if len(m) == 0 || userAgent == "" {
+		return false
+	}
+	ua := strings.ToLower(userAgent)
+	for _, p := range m {
+		if strings.Contains(ua, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerSynthetic(t *testing.T) {
+	agents := []string{"kube-probe/1.27", "Mozilla/5.0 (compatible; ACME-Monitor/2.0)", "Mozilla/5.0 (X11; Linux x86_64)"}
+
+	serve := func(config ZapLoggerConfig) *observer.ObservedLogs {
+		obs, logs := observer.New(zap.DebugLevel)
+
+		logger := zap.New(obs)
+
+		mw := ZapLoggerWithConfig(logger, config)
+
+		e := echo.New()
+		for _, ua := range agents {
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			req.Header.Set("User-Agent", ua)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := func(c echo.Context) error {
+				return c.String(http.StatusOK, "")
+			}
+
+			assert.Nil(t, mw(h)(c))
+		}
+		return logs
+	}
+
+	patterns := append(DefaultSyntheticAgents(), "acme-monitor")
+
+	tagged := serve(ZapLoggerConfig{SyntheticUserAgents: patterns, TagSynthetic: true})
+	assert.Equal(t, 3, tagged.Len())
+	assert.Equal(t, true, tagged.AllUntimed()[0].ContextMap()["synthetic"])
+	assert.Equal(t, true, tagged.AllUntimed()[1].ContextMap()["synthetic"])
+	assert.NotContains(t, tagged.AllUntimed()[2].ContextMap(), "synthetic")
+
+	skipped := serve(ZapLoggerConfig{SyntheticUserAgents: patterns, SkipSynthetic: true})
+	assert.Equal(t, 1, skipped.Len())
+	assert.Equal(t, agents[2], skipped.AllUntimed()[0].ContextMap()["user_agent"])
+}
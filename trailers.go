@@ -0,0 +1,62 @@
+package echozap
+
+import (
+	"net/http"
+	"net/textproto"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// canonicalKeys returns the canonical form of header keys
+func canonicalKeys(keys []string) []string {
+	canonical := make([]string, 0, len(keys))
+	for _, k := range keys {
+		canonical = append(canonical, textproto.CanonicalMIMEHeaderKey(k))
+	}
+	return canonical
+}
+
+// trailerMarshaler logs the listed trailers present in a header map, with only their first value unless multiValues is set
+type trailerMarshaler struct {
+	header      http.Header
+	keys        []string
+	multiValues bool
+}
+
+func (t trailerMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, k := range t.keys {
+		v, ok := t.lookup(k)
+		if !ok {
+			continue
+		}
+		if !t.multiValues {
+			enc.AddString(k, v[0])
+		} else if err := enc.AddArray(k, stringArray(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns the values of a trailer, either announced in the Trailer header or set with the http.TrailerPrefix
+func (t trailerMarshaler) lookup(key string) ([]string, bool) {
+	if v, ok := t.header[key]; ok && len(v) > 0 {
+		return v, true
+	}
+	if v, ok := t.header[http.TrailerPrefix+key]; ok && len(v) > 0 {
+		return v, true
+	}
+	return nil, false
+}
+
+// trailerFields returns the field holding the listed trailers, nothing when none of them is present
+func trailerFields(key string, header http.Header, keys []string, multiValues bool) []zapcore.Field {
+	t := trailerMarshaler{header: header, keys: keys, multiValues: multiValues}
+	for _, k := range keys {
+		if _, ok := t.lookup(k); ok {
+			return []zapcore.Field{zap.Object(key, t)}
+		}
+	}
+	return nil
+}
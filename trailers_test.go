@@ -0,0 +1,79 @@
+package echozap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerTrailers(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader("{}"))
+	req.Trailer = http.Header{"Checksum": []string{"abc"}}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		_, _ = io.ReadAll(c.Request().Body)
+
+		res := c.Response()
+		res.Header().Set("Trailer", "Grpc-Status")
+		if err := c.String(http.StatusOK, "{}"); err != nil {
+			return err
+		}
+		res.Header().Set("Grpc-Status", "0")
+		res.Header().Set(http.TrailerPrefix+"Grpc-Message", "ok")
+		return nil
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		LogResponseTrailers: []string{"grpc-status", "grpc-message", "grpc-status-details-bin"},
+		LogRequestTrailers:  []string{"checksum"},
+	})(h)(c)
+
+	assert.Nil(t, err)
+
+	logFields := logs.AllUntimed()[0].ContextMap()
+
+	assert.Equal(t, map[string]interface{}{"Grpc-Status": "0", "Grpc-Message": "ok"}, logFields["trailers"])
+	assert.Equal(t, map[string]interface{}{"Checksum": "abc"}, logFields["request_trailers"])
+	assert.Equal(t, "0", rec.Result().Trailer.Get("Grpc-Status"))
+}
+
+func TestZapLoggerTrailersMissing(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		LogResponseTrailers: []string{"Grpc-Status"},
+		LogRequestTrailers:  []string{"Checksum"},
+	})(h)(c)
+
+	assert.Nil(t, err)
+
+	logFields := logs.AllUntimed()[0].ContextMap()
+
+	assert.NotContains(t, logFields, "trailers")
+	assert.NotContains(t, logFields, "request_trailers")
+}
@@ -0,0 +1,95 @@
+package echozap
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// trimOrder lists the fields dropped, in order, from the entries over MaxEntryBytes.
+// The other fields, including status, request, latency and request_id, are never dropped.
+var trimOrder = []string{
+	"request_body",
+	"request_headers",
+	"request_trailers",
+	"trailers",
+	"stack",
+	"user_agent",
+}
+
+const (
+	// fieldOverhead accounts for the quotes, the colon and the comma around each field
+	fieldOverhead = 6
+	// entryOverhead accounts for the level, the time and the caller of the entry
+	entryOverhead = 128
+	// sizeSafetyFactor accounts for the escaping of the values
+	sizeSafetyFactor = 1.1
+)
+
+// sizeEncoder measures the fields of composite types by encoding them
+var sizeEncoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+
+// estimateFieldSize returns the approximate number of bytes of the encoded field
+func estimateFieldSize(f zapcore.Field) int {
+	n := len(f.Key) + fieldOverhead
+	switch f.Type {
+	case zapcore.SkipType:
+		return 0
+	case zapcore.StringType:
+		return n + len(f.String)
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		return n + len(f.Interface.([]byte))
+	case zapcore.BoolType:
+		return n + 5
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType, zapcore.StringerType, zapcore.ErrorType:
+		buf, err := sizeEncoder.EncodeEntry(zapcore.Entry{}, []zapcore.Field{f})
+		if err != nil {
+			return n
+		}
+		defer buf.Free()
+		return buf.Len()
+	default:
+		// Numbers, durations and times
+		return n + 20
+	}
+}
+
+// estimateEntrySize returns the approximate number of bytes of the encoded entry
+func estimateEntrySize(msg string, fields []zapcore.Field) int {
+	n := entryOverhead + len(msg)
+	for _, f := range fields {
+		n += estimateFieldSize(f)
+	}
+	return int(float64(n) * sizeSafetyFactor)
+}
+
+// trimEntry drops the fields of trimOrder until the estimated size of the entry is within max.
+// The dropped keys are listed by the trimmed_fields field, along with entry_trimmed=true.
+func trimEntry(msg string, fields []zapcore.Field, max int) []zapcore.Field {
+	size := estimateEntrySize(msg, fields)
+	if size <= max {
+		return fields
+	}
+
+	// The fields may be shared with the hooks
+	fields = append([]zapcore.Field(nil), fields...)
+
+	var trimmed []string
+	for _, key := range trimOrder {
+		for i := range fields {
+			if fields[i].Key != key || fields[i].Type == zapcore.SkipType {
+				continue
+			}
+			size -= int(float64(estimateFieldSize(fields[i])) * sizeSafetyFactor)
+			fields[i] = zap.Skip()
+			trimmed = append(trimmed, key)
+		}
+		if size <= max {
+			break
+		}
+	}
+
+	if len(trimmed) == 0 {
+		return fields
+	}
+	return append(fields, zap.Bool("entry_trimmed", true), zap.Strings("trimmed_fields", trimmed))
+}
@@ -0,0 +1,92 @@
+package echozap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTrimEntry(t *testing.T) {
+	fields := []zapcore.Field{
+		zap.String("request", "GET /something"),
+		zap.Int("status", http.StatusOK),
+		zap.ByteString("request_body", []byte(strings.Repeat("b", 1000))),
+		zap.String("user_agent", strings.Repeat("u", 300)),
+	}
+
+	assert.Equal(t, fields, trimEntry("", fields, 10000))
+
+	trimmed := trimEntry("", fields, 600)
+	assert.Equal(t, zapcore.SkipType, trimmed[2].Type)
+	assert.Equal(t, fields[3], trimmed[3])
+	assert.Equal(t, zap.Bool("entry_trimmed", true), trimmed[4])
+	assert.Equal(t, zap.Strings("trimmed_fields", []string{"request_body"}), trimmed[5])
+	assert.Equal(t, zapcore.ByteStringType, fields[2].Type, "the fields are copied")
+
+	trimmed = trimEntry("", fields, 200)
+	assert.Equal(t, zapcore.SkipType, trimmed[3].Type)
+	assert.Equal(t, zap.Strings("trimmed_fields", []string{"request_body", "user_agent"}), trimmed[5])
+
+	// Entries over the budget without optional fields are kept as they are
+	assert.Equal(t, fields[:2], trimEntry("", fields[:2], 10))
+}
+
+func TestEstimateFieldSize(t *testing.T) {
+	headers := http.Header{"X-Something": []string{strings.Repeat("h", 100)}}
+
+	assert.Equal(t, 0, estimateFieldSize(zap.Skip()))
+	assert.Equal(t, len("key")+fieldOverhead+len("value"), estimateFieldSize(zap.String("key", "value")))
+	assert.True(t, estimateFieldSize(zap.Object("request_headers", headerMarshaler(headers))) > 100)
+}
+
+func TestZapLoggerMaxEntryBytes(t *testing.T) {
+	e := echo.New()
+
+	h := func(c echo.Context) error {
+		if _, err := io.Copy(io.Discard, c.Request().Body); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		VerboseSampleRate: 1,
+		VerboseFields: VerboseFieldsConfig{
+			Headers:         true,
+			BodySnippetSize: 4096,
+		},
+		MaxEntryBytes: 1024,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/something", strings.NewReader(strings.Repeat("b", 4096)))
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	req.Header.Set("X-Something", strings.Repeat("h", 300))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert.Nil(t, mw(h)(c))
+
+	assert.Equal(t, 1, logs.Len())
+	logFields := logs.AllUntimed()[0].ContextMap()
+
+	assert.Equal(t, true, logFields["entry_trimmed"])
+	assert.Equal(t, []interface{}{"request_body"}, logFields["trimmed_fields"])
+	assert.NotContains(t, logFields, "request_body")
+	assert.Contains(t, logFields, "request_headers")
+	assert.Equal(t, int64(http.StatusOK), logFields["status"])
+	assert.Equal(t, "POST /something", logFields["request"])
+	assert.Equal(t, "req-1", logFields["request_id"])
+	assert.Contains(t, logFields, "latency")
+}
@@ -0,0 +1,51 @@
+package echozap
+
+import (
+	"reflect"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// typeEncoders are the field constructors of user defined types, never modified after construction
+type typeEncoders map[reflect.Type]func(key string, v interface{}) zapcore.Field
+
+func newTypeEncoders(m map[reflect.Type]func(key string, v interface{}) zapcore.Field) typeEncoders {
+	if len(m) == 0 {
+		return nil
+	}
+	encoders := make(typeEncoders, len(m))
+	for t, f := range m {
+		encoders[t] = f
+	}
+	return encoders
+}
+
+// has reports whether an encoder is registered for the type of v
+func (e typeEncoders) has(v interface{}) bool {
+	_, ok := e[reflect.TypeOf(v)]
+	return ok
+}
+
+// encode replaces the fields whose value has a registered encoder. fields is copied before the first replacement
+// since it may be shared by the hook returning it.
+func (e typeEncoders) encode(fields []zapcore.Field) []zapcore.Field {
+	if len(e) == 0 {
+		return fields
+	}
+	copied := false
+	for i, f := range fields {
+		if f.Interface == nil {
+			continue
+		}
+		enc, ok := e[reflect.TypeOf(f.Interface)]
+		if !ok {
+			continue
+		}
+		if !copied {
+			fields = append([]zapcore.Field(nil), fields...)
+			copied = true
+		}
+		fields[i] = enc(f.Key, f.Interface)
+	}
+	return fields
+}
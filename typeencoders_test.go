@@ -0,0 +1,64 @@
+package echozap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type money struct {
+	cents    int64
+	currency string
+}
+
+func TestZapLoggerTypeEncoders(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/something", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := func(c echo.Context) error {
+		c.Set("order.total", money{cents: 1250, currency: "EUR"})
+		Set(c, "refund", money{cents: 99, currency: "USD"})
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	shared := []zapcore.Field{zap.Any("fee", money{cents: 5, currency: "EUR"}), zap.Any("plain", 3)}
+
+	err := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		ContextFields: map[string]string{"order.total": "total"},
+		FieldsFunc: func(echo.Context, Values) []zapcore.Field {
+			return shared
+		},
+		TypeEncoders: map[reflect.Type]func(key string, v interface{}) zapcore.Field{
+			reflect.TypeOf(money{}): func(key string, v interface{}) zapcore.Field {
+				m := v.(money)
+				return zap.String(key, fmt.Sprintf("%d.%02d %s", m.cents/100, m.cents%100, m.currency))
+			},
+		},
+	})(h)(c)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, logs.Len())
+	fields := logs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, "12.50 EUR", fields["total"])
+	assert.Equal(t, "0.99 USD", fields["refund"])
+	assert.Equal(t, "0.05 EUR", fields["fee"])
+	assert.Equal(t, int64(3), fields["plain"])
+
+	// The fields returned by the hook are left untouched
+	assert.Equal(t, zapcore.ReflectType, shared[0].Type)
+}
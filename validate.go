@@ -0,0 +1,92 @@
+package echozap
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate reports the invalid values and the contradictory combinations of the configuration, all of them
+// joined in the returned error. ZapLoggerWithConfig and ZapLoggerWithHandle panic with it,
+// so misconfigurations are caught at startup.
+func (config ZapLoggerConfig) Validate() error {
+	var errs []error
+	conflict := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("echozap: "+format, args...))
+	}
+
+	if config.VerboseSampleRate < 0 || config.VerboseSampleRate > 1 {
+		conflict("VerboseSampleRate %v is outside of 0 to 1", config.VerboseSampleRate)
+	}
+	if config.ErrorRate.Threshold < 0 || config.ErrorRate.Threshold > 1 {
+		conflict("ErrorRate.Threshold %v is outside of 0 to 1", config.ErrorRate.Threshold)
+	}
+	if config.ErrorRate.Window < 0 || config.ErrorRate.Buckets < 0 || config.ErrorRate.MinRequests < 0 {
+		conflict("ErrorRate has a negative Window, Buckets or MinRequests")
+	} else if rate := config.ErrorRate.withDefaults(); rate.Threshold > 0 && int64(rate.Window) < int64(rate.Buckets) {
+		conflict("ErrorRate.Window %v is shorter than one nanosecond per bucket, with %d buckets", rate.Window, rate.Buckets)
+	}
+
+	for _, option := range []struct {
+		name  string
+		value int64
+	}{
+		{"SummaryInterval", int64(config.SummaryInterval)},
+		{"MaxPlausibleLatency", int64(config.MaxPlausibleLatency)},
+		{"WriteLatencyBudget", int64(config.WriteLatencyBudget)},
+		{"MaxEntryBytes", int64(config.MaxEntryBytes)},
+		{"CompactResyncEvery", int64(config.CompactResyncEvery)},
+	} {
+		if option.value < 0 {
+			conflict("%s is negative", option.name)
+		}
+	}
+
+	if config.SkipSynthetic && config.TagSynthetic {
+		conflict("TagSynthetic has no effect with SkipSynthetic, the synthetic entries are suppressed")
+	}
+	if config.WarnOnWriteError && !config.LogWriteErrors {
+		conflict("WarnOnWriteError requires LogWriteErrors")
+	}
+	if config.WarnOnSlowWrite && config.WriteLatencyBudget <= 0 {
+		conflict("WarnOnSlowWrite requires WriteLatencyBudget")
+	}
+	if config.LevelFromLogicalStatus && config.LogicalStatusFunc == nil {
+		conflict("LevelFromLogicalStatus requires LogicalStatusFunc")
+	}
+	if config.LevelOverrideHeader != "" && len(config.TrustedProxies) == 0 {
+		conflict("LevelOverrideHeader is never honored without TrustedProxies")
+	}
+	if config.Sink != nil && config.EmitFunc != nil {
+		conflict("EmitFunc has no effect with Sink, the sink writes the entries")
+	}
+	if config.ClientFingerprint != nil && len(config.ClientFingerprint.Secret) == 0 {
+		conflict("ClientFingerprint requires a Secret, the fingerprints could be recomputed from the IPs otherwise")
+	}
+
+	if _, err := newFieldMask(config.OnlyFields, config.ExcludeFields); err != nil {
+		errs = append(errs, err)
+	} else if len(config.OnlyFields) > 0 {
+		for _, name := range config.ExcludeFields {
+			for _, only := range config.OnlyFields {
+				if name == only {
+					conflict("field %q is in both OnlyFields and ExcludeFields", name)
+				}
+			}
+		}
+	}
+
+	if _, err := newPathPolicies(config.PathPolicies); err != nil {
+		errs = append(errs, err)
+	}
+	for i, policy := range config.PathPolicies {
+		if policy.MinStatus != 0 && (policy.MinStatus < 100 || policy.MinStatus > 599) {
+			conflict("PathPolicies[%d] has a MinStatus outside of 100 to 599", i)
+		}
+	}
+
+	if _, err := newFieldGroups(config.FieldGroups); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,174 @@
+package echozap
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ZapLoggerConfig
+		err    string
+	}{
+		{
+			name:   "verbose sample rate",
+			config: ZapLoggerConfig{VerboseSampleRate: 1.5},
+			err:    "echozap: VerboseSampleRate 1.5 is outside of 0 to 1",
+		},
+		{
+			name:   "error rate threshold",
+			config: ZapLoggerConfig{ErrorRate: ErrorRateConfig{Threshold: -0.1}},
+			err:    "echozap: ErrorRate.Threshold -0.1 is outside of 0 to 1",
+		},
+		{
+			name:   "error rate window",
+			config: ZapLoggerConfig{ErrorRate: ErrorRateConfig{Threshold: 0.1, Buckets: -1}},
+			err:    "echozap: ErrorRate has a negative Window, Buckets or MinRequests",
+		},
+		{
+			name:   "error rate window shorter than its buckets",
+			config: ZapLoggerConfig{ErrorRate: ErrorRateConfig{Threshold: 0.1, Window: 5}},
+			err:    "echozap: ErrorRate.Window 5ns is shorter than one nanosecond per bucket, with 10 buckets",
+		},
+		{
+			name:   "negative durations",
+			config: ZapLoggerConfig{SummaryInterval: -time.Second, MaxEntryBytes: -1},
+			err:    "echozap: SummaryInterval is negative\nechozap: MaxEntryBytes is negative",
+		},
+		{
+			name:   "synthetic",
+			config: ZapLoggerConfig{SkipSynthetic: true, TagSynthetic: true},
+			err:    "echozap: TagSynthetic has no effect with SkipSynthetic, the synthetic entries are suppressed",
+		},
+		{
+			name:   "write error",
+			config: ZapLoggerConfig{WarnOnWriteError: true},
+			err:    "echozap: WarnOnWriteError requires LogWriteErrors",
+		},
+		{
+			name:   "slow write",
+			config: ZapLoggerConfig{WarnOnSlowWrite: true},
+			err:    "echozap: WarnOnSlowWrite requires WriteLatencyBudget",
+		},
+		{
+			name:   "logical status",
+			config: ZapLoggerConfig{LevelFromLogicalStatus: true},
+			err:    "echozap: LevelFromLogicalStatus requires LogicalStatusFunc",
+		},
+		{
+			name:   "level override",
+			config: ZapLoggerConfig{LevelOverrideHeader: "X-Log-Level"},
+			err:    "echozap: LevelOverrideHeader is never honored without TrustedProxies",
+		},
+		{
+			name: "sink and emit func",
+			config: ZapLoggerConfig{
+				Sink:     &testSink{},
+				EmitFunc: func(*zap.Logger, zapcore.Level, string, []zapcore.Field) {},
+			},
+			err: "echozap: EmitFunc has no effect with Sink, the sink writes the entries",
+		},
+		{
+			name:   "fingerprint secret",
+			config: ZapLoggerConfig{ClientFingerprint: &FingerprintConfig{}},
+			err:    "echozap: ClientFingerprint requires a Secret, the fingerprints could be recomputed from the IPs otherwise",
+		},
+		{
+			name:   "unknown field",
+			config: ZapLoggerConfig{ExcludeFields: []string{"latencies"}},
+			err:    `echozap: unknown field "latencies" in ExcludeFields, valid names are: remote_ip, latency, host, request, status, size, user_agent, request_id, error`,
+		},
+		{
+			name:   "only and exclude",
+			config: ZapLoggerConfig{OnlyFields: []string{"status", "latency"}, ExcludeFields: []string{"latency"}},
+			err:    `echozap: field "latency" is in both OnlyFields and ExcludeFields`,
+		},
+		{
+			name:   "path policy",
+			config: ZapLoggerConfig{PathPolicies: []PathPolicy{{Path: "/a", SampleRate: 2}}},
+			err:    "echozap: PathPolicies[0] has a SampleRate outside of 0 to 1",
+		},
+		{
+			name:   "path policy status",
+			config: ZapLoggerConfig{PathPolicies: []PathPolicy{{Path: "/a"}, {Prefix: "/b", MinStatus: 40}}},
+			err:    "echozap: PathPolicies[1] has a MinStatus outside of 100 to 599",
+		},
+		{
+			name:   "field groups",
+			config: ZapLoggerConfig{FieldGroups: map[string]string{"status": ""}},
+			err:    `echozap: empty group name for field "status" in FieldGroups`,
+		},
+		{
+			name:   "every conflict is reported",
+			config: ZapLoggerConfig{VerboseSampleRate: -1, WarnOnWriteError: true, FieldGroups: map[string]string{"status": "host"}},
+			err: "echozap: VerboseSampleRate -1 is outside of 0 to 1\n" +
+				"echozap: WarnOnWriteError requires LogWriteErrors\n" +
+				`echozap: group name "host" in FieldGroups is a field name`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.config.Validate(), tt.err)
+			assert.PanicsWithError(t, tt.err, func() { ZapLoggerWithConfig(zap.NewNop(), tt.config) })
+		})
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	config := ZapLoggerConfig{
+		IncludeRequestLogMessage: true,
+		VerboseSampleRate:        0.01,
+		VerboseFields:            VerboseFieldsConfig{Headers: true, BodySnippetSize: 256},
+		LogWriteErrors:           true,
+		WarnOnWriteError:         true,
+		HealthCheckPaths:         []string{"/healthz"},
+		OnlyFields:               []string{"status", "latency", "request", "request_id"},
+		ErrorRate:                ErrorRateConfig{Threshold: 0.05, Window: 30 * time.Second, Verbose: true},
+		SummaryInterval:          time.Minute,
+		LevelOverrideHeader:      "X-Log-Level",
+		TrustedProxies:           []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		TagSynthetic:             true,
+		LogicalStatusFunc:        func(echo.Context) (int, bool) { return 0, false },
+		LevelFromLogicalStatus:   true,
+		WriteLatencyBudget:       time.Second,
+		WarnOnSlowWrite:          true,
+		ClientFingerprint:        &FingerprintConfig{Secret: []byte("s3cr3t")},
+		PathPolicies:             []PathPolicy{{Prefix: "/static/", SampleRate: 0.1}, {Route: "/users/:id", MinStatus: 400}},
+		FieldGroups:              map[string]string{"status": "http", "latency": "http"},
+		MaxEntryBytes:            16 << 10,
+	}
+
+	assert.Nil(t, config.Validate())
+	assert.Nil(t, DefaultZapLoggerConfig.Validate())
+	assert.NotPanics(t, func() {
+		_, handle := ZapLoggerWithHandle(zap.NewNop(), config)
+		_ = handle.Close()
+	})
+}
+
+func TestZapLoggerInvalidConfig(t *testing.T) {
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	config := ZapLoggerConfig{WarnOnSlowWrite: true}
+	err := "echozap: WarnOnSlowWrite requires WriteLatencyBudget"
+	assert.PanicsWithError(t, err, func() { ZapLoggerWithConfig(logger, config) })
+	assert.PanicsWithError(t, err, func() { ZapLoggerWithHandle(logger, config) })
+
+	assert.Equal(t, 0, logs.Len())
+}
+
+type testSink struct{}
+
+func (testSink) Emit(echo.Context, Values) error          { return nil }
+func (testSink) Enabled(echo.Context, zapcore.Level) bool { return true }
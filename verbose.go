@@ -0,0 +1,158 @@
+package echozap
+
+import (
+	"crypto/tls"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// VerboseFieldsConfig defines the extra fields included in verbose entries
+type VerboseFieldsConfig struct {
+	// Whether to include the request headers
+	Headers bool
+	// Maximum number of request body bytes to include. Zero disables the body snippet
+	BodySnippetSize int
+	// Whether to include the TLS connection details
+	TLS bool
+	// Request headers whose values are replaced by a placeholder in the logged headers. Defaults to DefaultRedactedHeaders
+	RedactedHeaders []string
+	// Whether to log the values of the RedactedHeaders in clear text
+	DisableRedaction bool
+}
+
+// DefaultRedactedHeaders are the request headers holding credentials, redacted from the verbose headers by default
+var DefaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// redactedValue replaces the values of the redacted headers
+const redactedValue = "[REDACTED]"
+
+// randFloat64 is used to sample requests without an ID
+var randFloat64 = rand.Float64
+
+// sampleVerbose reports whether the request should be logged with the verbose field set.
+func sampleVerbose(id string, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	if rate >= 1 {
+		return true
+	}
+	if id == "" {
+		return randFloat64() < rate
+	}
+
+	return hashFraction(id) < rate
+}
+
+// hashFraction maps s to a stable value in [0, 1].
+// FNV alone clusters similar IDs (e.g. sequential ones), so the sum goes through a 64-bit finalizer.
+func hashFraction(s string) float64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return float64(x) / math.MaxUint64
+}
+
+// fields returns the verbose fields of the request, whose headers are logged from header
+func (v VerboseFieldsConfig) fields(req *http.Request, header http.Header, body *snippetReader, multiValues bool) []zapcore.Field {
+	var fields []zapcore.Field
+
+	if v.Headers {
+		if !v.DisableRedaction {
+			header = redactHeaders(header, v.RedactedHeaders)
+		}
+		if multiValues {
+			fields = append(fields, zap.Object("request_headers", multiHeaderMarshaler(header)))
+		} else {
+			fields = append(fields, zap.Object("request_headers", headerMarshaler(header)))
+		}
+	}
+	if body != nil {
+		fields = append(fields, zap.ByteString("request_body", body.buf))
+	}
+	if v.TLS && req.TLS != nil {
+		fields = append(fields, zap.Object("tls", tlsMarshaler{req.TLS}))
+	}
+
+	return fields
+}
+
+// redactHeaders returns h with the values of the names headers replaced by redactedValue, copying h only when needed
+func redactHeaders(h http.Header, names []string) http.Header {
+	if len(names) == 0 {
+		names = DefaultRedactedHeaders
+	}
+
+	var redacted http.Header
+	for _, name := range names {
+		k := http.CanonicalHeaderKey(name)
+		values, ok := h[k]
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = h.Clone()
+		}
+		for i := range values {
+			redacted[k][i] = redactedValue
+		}
+	}
+	if redacted == nil {
+		return h
+	}
+	return redacted
+}
+
+// headerMarshaler logs HTTP headers as an object, joining repeated values with a comma
+type headerMarshaler http.Header
+
+func (h headerMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range h {
+		enc.AddString(k, strings.Join(v, ", "))
+	}
+	return nil
+}
+
+type tlsMarshaler struct {
+	state *tls.ConnectionState
+}
+
+func (t tlsMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("version", tls.VersionName(t.state.Version))
+	enc.AddString("cipher_suite", tls.CipherSuiteName(t.state.CipherSuite))
+	enc.AddString("server_name", t.state.ServerName)
+	enc.AddBool("resumed", t.state.DidResume)
+	return nil
+}
+
+// snippetReader keeps a copy of the first limit bytes read from the request body
+type snippetReader struct {
+	io.ReadCloser
+	limit int
+	buf   []byte
+}
+
+func (r *snippetReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if remaining := r.limit - len(r.buf); remaining > 0 && n > 0 {
+		if n < remaining {
+			remaining = n
+		}
+		r.buf = append(r.buf, p[:remaining]...)
+	}
+	return n, err
+}
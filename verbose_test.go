@@ -0,0 +1,161 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerVerboseSample(t *testing.T) {
+	e := echo.New()
+
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		VerboseSampleRate: 0.5,
+		VerboseFields: VerboseFieldsConfig{
+			Headers: true,
+		},
+	})
+
+	// hashFraction("req-1") ~ 0.26 and hashFraction("req-2") ~ 0.70
+	for _, id := range []string{"req-1", "req-2", "req-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/something", nil)
+		req.Header.Set(echo.HeaderXRequestID, id)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, 3, len(entries))
+
+	verbose := entries[0].ContextMap()
+	assert.Equal(t, true, verbose["verbose_sample"])
+	assert.Equal(t, map[string]interface{}{"X-Request-Id": "req-1"}, verbose["request_headers"])
+
+	slim := entries[1].ContextMap()
+	assert.NotContains(t, slim, "verbose_sample")
+	assert.NotContains(t, slim, "request_headers")
+
+	assert.Equal(t, true, entries[2].ContextMap()["verbose_sample"])
+}
+
+func TestZapLoggerVerboseSampleWithoutRequestID(t *testing.T) {
+	defer func(f func() float64) { randFloat64 = f }(randFloat64)
+
+	e := echo.New()
+
+	h := func(c echo.Context) error {
+		_, _ = c.Request().Body.Read(make([]byte, 64))
+		return c.String(http.StatusOK, "")
+	}
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	mw := ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		VerboseSampleRate: 0.01,
+		VerboseFields: VerboseFieldsConfig{
+			BodySnippetSize: 5,
+		},
+	})
+
+	for _, r := range []float64{0.005, 0.5} {
+		randFloat64 = func() float64 { return r }
+
+		req := httptest.NewRequest(http.MethodPost, "/something", strings.NewReader("hello world"))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, 2, len(entries))
+
+	assert.Equal(t, true, entries[0].ContextMap()["verbose_sample"])
+	assert.Equal(t, "hello", entries[0].ContextMap()["request_body"])
+	assert.NotContains(t, entries[1].ContextMap(), "request_body")
+}
+
+func TestZapLoggerVerboseHeadersRedaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  VerboseFieldsConfig
+		headers map[string]interface{}
+	}{
+		{
+			name:   "default",
+			fields: VerboseFieldsConfig{Headers: true},
+			headers: map[string]interface{}{
+				"Authorization":       "[REDACTED]",
+				"Cookie":              "[REDACTED], [REDACTED]",
+				"Proxy-Authorization": "[REDACTED]",
+				"X-Api-Key":           "key",
+			},
+		},
+		{
+			name:   "custom",
+			fields: VerboseFieldsConfig{Headers: true, RedactedHeaders: []string{"x-api-key"}},
+			headers: map[string]interface{}{
+				"Authorization":       "Bearer token",
+				"Cookie":              "a=1, b=2",
+				"Proxy-Authorization": "Basic cHJveHk=",
+				"X-Api-Key":           "[REDACTED]",
+			},
+		},
+		{
+			name:   "disabled",
+			fields: VerboseFieldsConfig{Headers: true, DisableRedaction: true},
+			headers: map[string]interface{}{
+				"Authorization":       "Bearer token",
+				"Cookie":              "a=1, b=2",
+				"Proxy-Authorization": "Basic cHJveHk=",
+				"X-Api-Key":           "key",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Add("Cookie", "a=1")
+			req.Header.Add("Cookie", "b=2")
+			req.Header.Set("Proxy-Authorization", "Basic cHJveHk=")
+			req.Header.Set("X-Api-Key", "key")
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			assert.Nil(t, ZapLoggerWithConfig(logger, ZapLoggerConfig{
+				VerboseSampleRate: 1,
+				VerboseFields:     tt.fields,
+			})(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c))
+
+			assert.Equal(t, 1, logs.Len())
+			assert.Equal(t, tt.headers, logs.AllUntimed()[0].ContextMap()["request_headers"])
+			// The request itself is left untouched
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		})
+	}
+}
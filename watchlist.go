@@ -0,0 +1,126 @@
+package echozap
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WatchRule matches the requests logged to the WatchLogger. All the set criteria must match,
+// so an empty rule matches every request. Rules are evaluated once the handler returned.
+type WatchRule struct {
+	// Client IP, as returned by c.RealIP()
+	RemoteIP string
+	// Prefix of the request path
+	PathPrefix string
+	// Request header that must have the value HeaderValue
+	Header      string
+	HeaderValue string
+	// Match is an arbitrary predicate. It is called once the handler returned, so it sees the route, the response
+	// status and the values the handler stored in the context
+	Match func(c echo.Context) bool
+}
+
+// WatchID identifies a rule added to a Watchlist
+type WatchID uint64
+
+type watchEntry struct {
+	id   WatchID
+	rule WatchRule
+}
+
+// Watchlist is the set of rules selecting the requests logged to the WatchLogger. It can be changed at runtime.
+// Rules are replaced copy-on-write so matching an empty watchlist costs a single atomic load.
+type Watchlist struct {
+	mu     sync.Mutex
+	nextID WatchID
+	rules  atomic.Pointer[[]watchEntry]
+}
+
+// Add adds a rule to the watchlist and returns its ID
+func (w *Watchlist) Add(rule WatchRule) WatchID {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.nextID++
+	rules := append(w.load(), watchEntry{id: w.nextID, rule: rule})
+	w.rules.Store(&rules)
+	return w.nextID
+}
+
+// Remove removes a rule from the watchlist
+func (w *Watchlist) Remove(id WatchID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var rules []watchEntry
+	for _, e := range w.load() {
+		if e.id != id {
+			rules = append(rules, e)
+		}
+	}
+	w.rules.Store(&rules)
+}
+
+// Clear removes all the rules from the watchlist
+func (w *Watchlist) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.rules.Store(nil)
+}
+
+// load returns a copy of the current rules
+func (w *Watchlist) load() []watchEntry {
+	p := w.rules.Load()
+	if p == nil {
+		return nil
+	}
+	return append([]watchEntry(nil), *p...)
+}
+
+// matches reports whether any rule matches the request, once the handler returned
+func (w *Watchlist) matches(c echo.Context) bool {
+	p := w.rules.Load()
+	if p == nil {
+		return false
+	}
+	for _, e := range *p {
+		if e.rule.matchesRequest(c) && (e.rule.Match == nil || e.rule.Match(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayMatch reports whether any rule matches the request before the handler runs, ignoring the Match predicates.
+// It decides whether the request body must be captured for the watch entry.
+func (w *Watchlist) mayMatch(c echo.Context) bool {
+	p := w.rules.Load()
+	if p == nil {
+		return false
+	}
+	for _, e := range *p {
+		if e.rule.matchesRequest(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesRequest reports whether the criteria of the rule reading the request match
+func (r WatchRule) matchesRequest(c echo.Context) bool {
+	req := c.Request()
+	if r.PathPrefix != "" && !strings.HasPrefix(req.URL.Path, r.PathPrefix) {
+		return false
+	}
+	if r.Header != "" && req.Header.Get(r.Header) != r.HeaderValue {
+		return false
+	}
+	if r.RemoteIP != "" && c.RealIP() != r.RemoteIP {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerWatchlist(t *testing.T) {
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+	watchObs, watchLogs := observer.New(zap.DebugLevel)
+
+	mw, handle := ZapLoggerWithHandle(zap.New(obs), ZapLoggerConfig{
+		WatchLogger: zap.New(watchObs),
+		VerboseFields: VerboseFieldsConfig{
+			Headers:         true,
+			BodySnippetSize: 16,
+		},
+	})
+
+	serve := func(path, user string) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("payload"))
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		h := func(c echo.Context) error {
+			_, _ = c.Request().Body.Read(make([]byte, 16))
+			return c.String(http.StatusOK, "")
+		}
+
+		assert.Nil(t, mw(h)(c))
+	}
+
+	serve("/api/orders", "alice")
+	assert.Equal(t, 0, watchLogs.Len())
+
+	byPath := handle.Watchlist().Add(WatchRule{PathPrefix: "/api/orders"})
+	handle.Watchlist().Add(WatchRule{Header: "X-User", HeaderValue: "bob"})
+	handle.Watchlist().Add(WatchRule{Match: func(c echo.Context) bool { return c.Request().URL.Path == "/debug" }})
+
+	serve("/api/orders/1", "alice")
+	serve("/api/users", "bob")
+	serve("/api/users", "alice")
+	serve("/debug", "alice")
+
+	assert.Equal(t, 3, watchLogs.Len())
+
+	watched := watchLogs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, true, watched["watched"])
+	assert.Equal(t, "payload", watched["request_body"])
+	assert.NotNil(t, watched["request_headers"])
+	assert.Equal(t, "POST /api/orders/1", watched["request"])
+
+	handle.Watchlist().Remove(byPath)
+	serve("/api/orders/2", "alice")
+	assert.Equal(t, 3, watchLogs.Len())
+
+	handle.Watchlist().Clear()
+	serve("/api/users", "bob")
+	assert.Equal(t, 3, watchLogs.Len())
+
+	assert.Equal(t, 7, logs.Len())
+	for _, entry := range logs.AllUntimed() {
+		assert.NotContains(t, entry.ContextMap(), "watched")
+		assert.NotContains(t, entry.ContextMap(), "request_body")
+	}
+}
+
+func TestZapLoggerWatchlistMatchAfterHandler(t *testing.T) {
+	e := echo.New()
+
+	obs, _ := observer.New(zap.DebugLevel)
+	watchObs, watchLogs := observer.New(zap.DebugLevel)
+
+	mw, handle := ZapLoggerWithHandle(zap.New(obs), ZapLoggerConfig{
+		WatchLogger:   zap.New(watchObs),
+		VerboseFields: VerboseFieldsConfig{BodySnippetSize: 16},
+	})
+
+	// The route, the status and the values set by the handler are only known once it returned
+	handle.Watchlist().Add(WatchRule{Match: func(c echo.Context) bool {
+		return c.Path() == "/users/:id" && c.Response().Status == http.StatusConflict && c.Get("user") == "u-1"
+	}})
+
+	e.Use(mw)
+	e.POST("/users/:id", func(c echo.Context) error {
+		_, _ = c.Request().Body.Read(make([]byte, 16))
+		c.Set("user", c.Param("id"))
+		return c.NoContent(http.StatusConflict)
+	})
+
+	for _, path := range []string{"/users/u-1", "/users/u-2"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, strings.NewReader("payload")))
+	}
+
+	assert.Equal(t, 1, watchLogs.Len())
+	watched := watchLogs.AllUntimed()[0].ContextMap()
+	assert.Equal(t, "POST /users/u-1", watched["request"])
+	assert.Equal(t, "payload", watched["request_body"])
+}
@@ -0,0 +1,48 @@
+package echozap
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// writeTimer measures the write phase of a response, from the header write to the end of the last body write,
+// using the response hooks
+type writeTimer struct {
+	first, last time.Time
+}
+
+func newWriteTimer(res *echo.Response, now func() time.Time) *writeTimer {
+	w := &writeTimer{}
+	res.Before(func() {
+		if w.first.IsZero() {
+			w.first = now()
+		}
+	})
+	res.After(func() {
+		w.last = now()
+	})
+	return w
+}
+
+// latency returns the duration of the write phase, zero when the body was not written
+func (w *writeTimer) latency() time.Duration {
+	if w.first.IsZero() || w.last.Before(w.first) {
+		return 0
+	}
+	return w.last.Sub(w.first)
+}
+
+// fields returns the slow write fields when the write phase exceeded the budget
+func (w *writeTimer) fields(budget time.Duration) []zapcore.Field {
+	latency := w.latency()
+	if latency <= budget {
+		return nil
+	}
+	return []zapcore.Field{
+		zap.Bool("slow_write", true),
+		zap.Stringer("write_latency", latency),
+	}
+}
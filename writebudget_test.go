@@ -0,0 +1,86 @@
+package echozap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// delayingWriter advances the clock on each write, like a slow client
+type delayingWriter struct {
+	http.ResponseWriter
+	delay func()
+}
+
+func (w *delayingWriter) Write(p []byte) (int, error) {
+	w.delay()
+	return w.ResponseWriter.Write(p)
+}
+
+func TestZapLoggerWriteLatencyBudget(t *testing.T) {
+	tests := []struct {
+		name       string
+		writeDelay time.Duration
+		warn       bool
+		slow       bool
+		level      zapcore.Level
+	}{
+		{name: "fast client", writeDelay: 0, level: zapcore.InfoLevel},
+		{name: "slow client", writeDelay: 400 * time.Millisecond, slow: true, level: zapcore.InfoLevel},
+		{name: "slow client escalated", writeDelay: 400 * time.Millisecond, warn: true, slow: true, level: zapcore.WarnLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			mw, handle := ZapLoggerWithHandle(logger, ZapLoggerConfig{
+				WriteLatencyBudget: time.Second,
+				WarnOnSlowWrite:    tt.warn,
+			})
+
+			clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			handle.now = func() time.Time { return clock }
+			c.Response().Writer = &delayingWriter{ResponseWriter: rec, delay: func() { clock = clock.Add(tt.writeDelay) }}
+
+			// The handler is fast, the chunks are written to the client slowly
+			h := func(c echo.Context) error {
+				c.Response().WriteHeader(http.StatusOK)
+				for i := 0; i < 3; i++ {
+					if _, err := c.Response().Write([]byte("chunk")); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			assert.Nil(t, mw(h)(c))
+
+			assert.Equal(t, 1, logs.Len())
+			entry := logs.AllUntimed()[0]
+			assert.Equal(t, tt.level, entry.Level)
+			fields := entry.ContextMap()
+			if !tt.slow {
+				assert.NotContains(t, fields, "slow_write")
+				assert.NotContains(t, fields, "write_latency")
+				return
+			}
+			assert.Equal(t, true, fields["slow_write"])
+			assert.Equal(t, "1.2s", fields["write_latency"])
+		})
+	}
+}
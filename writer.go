@@ -0,0 +1,129 @@
+package echozap
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// trackingWriter wraps the response writer to record the first write error, the number of bytes written
+// and the final status sent to the underlying writer. It is installed through wrap, which keeps the optional Flusher and
+// Hijacker interfaces of the underlying writer only when it implements them, and exposes it through Unwrap for http.ResponseController.
+type trackingWriter struct {
+	http.ResponseWriter
+	written  int64
+	intended int64
+	err      error
+	// status is the first non-informational status written, 0 until then
+	status int
+}
+
+func (w *trackingWriter) WriteHeader(code int) {
+	if w.status == 0 && !isInterim(code) {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *trackingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.record(int64(len(p)), int64(n), err)
+	return n, err
+}
+
+// ReadFrom keeps the sendfile path of the underlying writer when available
+func (w *trackingWriter) ReadFrom(r io.Reader) (int64, error) {
+	rf, ok := w.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		return io.Copy(writerOnly{w}, r)
+	}
+
+	n, err := rf.ReadFrom(r)
+	w.record(n, n, err)
+	return n, err
+}
+
+// wrap returns w with the Flusher and Hijacker interfaces implemented by the underlying writer, so type checks on the
+// wrapper give the same answer as on the underlying writer
+func (w *trackingWriter) wrap() http.ResponseWriter {
+	_, flusher := w.ResponseWriter.(http.Flusher)
+	_, hijacker := w.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case flusher && hijacker:
+		return flushHijackWriter{w}
+	case flusher:
+		return flushWriter{w}
+	case hijacker:
+		return hijackWriter{w}
+	default:
+		return w
+	}
+}
+
+type flushWriter struct {
+	*trackingWriter
+}
+
+func (w flushWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijackWriter struct {
+	*trackingWriter
+}
+
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type flushHijackWriter struct {
+	*trackingWriter
+}
+
+func (w flushHijackWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w flushHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Unwrap returns the underlying response writer
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *trackingWriter) record(intended, written int64, err error) {
+	w.intended += intended
+	w.written += written
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// fields returns the write error fields. The intended size is the Content-Length announced by the handler when larger
+// than what it tried to write.
+func (w *trackingWriter) fields(res *echo.Response) []zapcore.Field {
+	intended := w.intended
+	if n, err := strconv.ParseInt(res.Header().Get(echo.HeaderContentLength), 10, 64); err == nil && n > intended {
+		intended = n
+	}
+
+	return []zapcore.Field{
+		zap.String("write_error", w.err.Error()),
+		zap.Int64("bytes_written", w.written),
+		zap.Int64("bytes_intended", intended),
+	}
+}
+
+// writerOnly hides the ReadFrom method so io.Copy does not recurse into it
+type writerOnly struct {
+	io.Writer
+}
@@ -0,0 +1,152 @@
+package echozap
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLoggerWriteError(t *testing.T) {
+	const size = 64 << 20
+
+	e := echo.New()
+
+	obs, logs := observer.New(zap.DebugLevel)
+
+	logger := zap.New(obs)
+
+	e.Use(ZapLoggerWithConfig(logger, ZapLoggerConfig{
+		LogWriteErrors:   true,
+		WarnOnWriteError: true,
+	}))
+
+	e.GET("/download", func(c echo.Context) error {
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentLength, strconv.Itoa(size))
+		res.WriteHeader(http.StatusOK)
+
+		chunk := make([]byte, 32<<10)
+		for written := 0; written < size; written += len(chunk) {
+			if _, err := res.Write(chunk); err != nil {
+				// Like most handlers, ignore the write error
+				return nil
+			}
+		}
+		return nil
+	})
+
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		e.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	assert.Nil(t, err)
+
+	_, err = io.WriteString(conn, "GET /download HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	assert.Nil(t, err)
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	_ = conn.Close()
+
+	<-done
+
+	assert.Equal(t, 1, logs.Len())
+
+	entry := logs.AllUntimed()[0]
+	logFields := entry.ContextMap()
+
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	assert.NotEmpty(t, logFields["write_error"])
+	assert.Equal(t, int64(size), logFields["bytes_intended"])
+	assert.True(t, logFields["bytes_written"].(int64) < size)
+}
+
+func TestZapLoggerWriteErrorPreservesInterfaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		writer   http.ResponseWriter
+		flusher  bool
+		hijacker bool
+	}{
+		{name: "plain", writer: plainWriter{httptest.NewRecorder()}},
+		{name: "flusher", writer: httptest.NewRecorder(), flusher: true},
+		{name: "hijacker", writer: hijackableWriter{plainWriter{httptest.NewRecorder()}}, hijacker: true},
+		{name: "both", writer: flushHijackableWriter{httptest.NewRecorder()}, flusher: true, hijacker: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/something", nil)
+			c := e.NewContext(req, tt.writer)
+
+			h := func(c echo.Context) error {
+				w := c.Response().Writer
+
+				_, ok := w.(http.Flusher)
+				assert.Equal(t, tt.flusher, ok)
+				_, ok = w.(http.Hijacker)
+				assert.Equal(t, tt.hijacker, ok)
+				_, ok = w.(io.ReaderFrom)
+				assert.True(t, ok)
+
+				if tt.hijacker {
+					_, _, err := w.(http.Hijacker).Hijack()
+					assert.Equal(t, http.ErrNotSupported, err)
+				}
+
+				return c.String(http.StatusOK, "ok")
+			}
+
+			obs, logs := observer.New(zap.DebugLevel)
+
+			logger := zap.New(obs)
+
+			err := ZapLoggerWithConfig(logger, ZapLoggerConfig{LogWriteErrors: true})(h)(c)
+
+			assert.Nil(t, err)
+
+			entry := logs.AllUntimed()[0]
+
+			assert.Equal(t, zapcore.InfoLevel, entry.Level)
+			assert.NotContains(t, entry.ContextMap(), "write_error")
+			assert.Equal(t, tt.writer, c.Response().Writer)
+		})
+	}
+}
+
+// plainWriter hides the optional interfaces of the recorder
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+type hijackableWriter struct {
+	plainWriter
+}
+
+func (hijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+type flushHijackableWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (flushHijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}